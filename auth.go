@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/chronophylos/chb3/token"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// authorize exchanges an authorization code for a token of the bot account
+// and stores it. If code is empty the user is asked for it.
+func authorize(code string) {
+	readConfig()
+
+	for _, key := range []string{"twitch.username", "twitch.clientid", "twitch.secret"} {
+		if !viper.IsSet(key) {
+			log.Fatal().Msgf("%s is not set.", key)
+		}
+	}
+	username := viper.GetString("twitch.username")
+
+	helixClient, err := newHelixClient()
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Could not create helix client")
+	}
+
+	if code == "" {
+		fmt.Printf("Log in as %s and open\n\n\t%s\n\n", username, helixClient.GetAuthorizationURL("", true))
+		fmt.Print("Paste the code parameter of the URL you are redirected to: ")
+
+		code, err = bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Could not read code")
+		}
+		code = strings.TrimSpace(code)
+	}
+
+	stateClient, err := state.NewClient("mongodb://localhost:27017")
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Could not create State Client")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		stateClient.Disconnect(ctx)
+	}()
+
+	tokens := token.NewSource(username, helixClient, stateClient)
+	if err := tokens.Exchange(code); err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Could not authorize")
+	}
+
+	log.Info().
+		Str("username", username).
+		Time("expires", tokens.ExpiresAt()).
+		Msg("Stored token")
+}
@@ -0,0 +1,323 @@
+package chatlog
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/chronophylos/chb3/web"
+	"github.com/gempir/go-twitch-irc/v2"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UserLookup finds users by name, eg. *state.Client.
+type UserLookup interface {
+	GetUserByName(name string) (state.User, error)
+}
+
+// API serves logs with the same URLs and formats as justlog:
+//
+//	GET /list?channel=<channel>[&user=<user>|&userid=<id>]
+//	GET /channel/<channel>
+//	GET /channel/<channel>/<year>/<month>/<day>
+//	GET /channel/<channel>/user/<user>
+//	GET /channel/<channel>/user/<user>/<year>/<month>
+//	GET /channel/<channel>/user/<user>/random
+//
+// user can be replaced by userid followed by a twitch ID. Logs are plain text
+// unless the query contains json or raw or type=json or type=raw. reverse
+// returns the newest lines first.
+type API struct {
+	// Logged reports wheather the logs of channel are served. All channels
+	// are served if it is nil.
+	Logged func(channel string) bool
+
+	store Store
+	users UserLookup
+}
+
+// NewAPI creates an API serving the logs in store.
+func NewAPI(store Store, users UserLookup) *API {
+	return &API{store: store, users: users}
+}
+
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		web.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for i := range parts {
+		parts[i] = strings.ToLower(parts[i])
+	}
+
+	if len(parts) >= 2 && parts[0] == "channel" && !a.channel(w, parts[1]) {
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && parts[0] == "list":
+		a.list(w, r)
+	case len(parts) == 2 && parts[0] == "channel":
+		a.redirect(w, r, parts[1], "")
+	case len(parts) >= 4 && parts[0] == "channel" && (parts[2] == "user" || parts[2] == "userid"):
+		userID, ok := a.userID(w, parts[2], parts[3])
+		if !ok {
+			return
+		}
+
+		switch {
+		case len(parts) == 4:
+			a.redirect(w, r, parts[1], userID)
+		case len(parts) == 5 && parts[4] == "random":
+			a.random(w, parts[1], userID)
+		case len(parts) == 6:
+			a.userMonth(w, r, parts[1], userID, parts[4], parts[5])
+		default:
+			web.WriteError(w, http.StatusNotFound, "not found")
+		}
+	case len(parts) == 5 && parts[0] == "channel":
+		a.channelDay(w, r, parts[1], parts[2], parts[3], parts[4])
+	default:
+		web.WriteError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// channel reports wheather the logs of channel can be served. Otherwise it
+// writes an error.
+func (a *API) channel(w http.ResponseWriter, channel string) bool {
+	if !ValidChannel(channel) {
+		web.WriteError(w, http.StatusBadRequest, "invalid channel")
+		return false
+	}
+	if a.Logged != nil && !a.Logged(channel) {
+		web.WriteError(w, http.StatusNotFound, "channel is not logged")
+		return false
+	}
+	return true
+}
+
+// userID returns the twitch ID of the user named name or name itself if kind
+// is userid.
+func (a *API) userID(w http.ResponseWriter, kind, name string) (string, bool) {
+	if kind == "userid" {
+		return name, true
+	}
+
+	user, err := a.users.GetUserByName(name)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			web.WriteError(w, http.StatusNotFound, "unknown user")
+			return "", false
+		}
+		log.Error().Err(err).Str("user", name).Msg("Getting user")
+		web.WriteError(w, http.StatusInternalServerError, err.Error())
+		return "", false
+	}
+
+	return user.ID, true
+}
+
+type availableLog struct {
+	Year  string `json:"year"`
+	Month string `json:"month"`
+	Day   string `json:"day,omitempty"`
+}
+
+func (a *API) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	channel := strings.ToLower(query.Get("channel"))
+	if channel == "" {
+		web.WriteError(w, http.StatusBadRequest, "channel is missing")
+		return
+	}
+	if !a.channel(w, channel) {
+		return
+	}
+
+	var userID string
+	switch {
+	case query.Get("userid") != "":
+		userID = query.Get("userid")
+	case query.Get("user") != "":
+		var ok bool
+		if userID, ok = a.userID(w, "user", strings.ToLower(query.Get("user"))); !ok {
+			return
+		}
+	}
+
+	days, err := a.store.GetLogDays(channel, userID)
+	if err != nil {
+		log.Error().Err(err).Str("channel", channel).Msg("Getting log days")
+		web.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Newest first like justlog. Users are listed by month, channels by day.
+	available := []availableLog{}
+	for i := len(days) - 1; i >= 0; i-- {
+		entry := availableLog{
+			Year:  strconv.Itoa(days[i].Year()),
+			Month: strconv.Itoa(int(days[i].Month())),
+		}
+		if userID == "" {
+			entry.Day = strconv.Itoa(days[i].Day())
+		} else if len(available) > 0 && available[len(available)-1] == entry {
+			continue
+		}
+		available = append(available, entry)
+	}
+
+	web.WriteJSON(w, http.StatusOK, map[string]interface{}{"availableLogs": available})
+}
+
+// redirect redirects to the newest day of channel or the newest month of the
+// user.
+func (a *API) redirect(w http.ResponseWriter, r *http.Request, channel, userID string) {
+	days, err := a.store.GetLogDays(channel, userID)
+	if err != nil {
+		log.Error().Err(err).Str("channel", channel).Msg("Getting log days")
+		web.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(days) == 0 {
+		web.WriteError(w, http.StatusNotFound, "no logs found")
+		return
+	}
+	last := days[len(days)-1]
+
+	path := fmt.Sprintf("/channel/%s/%d/%d/%d", channel, last.Year(), last.Month(), last.Day())
+	if userID != "" {
+		path = fmt.Sprintf("/channel/%s/userid/%s/%d/%d", channel, userID, last.Year(), last.Month())
+	}
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+
+	http.Redirect(w, r, path, http.StatusFound)
+}
+
+func (a *API) channelDay(w http.ResponseWriter, r *http.Request, channel, year, month, day string) {
+	from, ok := parseDate(year, month, day)
+	if !ok {
+		web.WriteError(w, http.StatusBadRequest, "invalid date")
+		return
+	}
+
+	a.serve(w, r, channel, "", from, from.AddDate(0, 0, 1))
+}
+
+func (a *API) userMonth(w http.ResponseWriter, r *http.Request, channel, userID, year, month string) {
+	from, ok := parseDate(year, month, "1")
+	if !ok {
+		web.WriteError(w, http.StatusBadRequest, "invalid date")
+		return
+	}
+
+	a.serve(w, r, channel, userID, from, from.AddDate(0, 1, 0))
+}
+
+func (a *API) random(w http.ResponseWriter, channel, userID string) {
+	line, err := a.store.GetRandomLogLine(channel, userID)
+	if err != nil {
+		log.Error().Err(err).Str("channel", channel).Msg("Getting random line")
+		web.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if line == nil {
+		web.WriteError(w, http.StatusNotFound, "no logs found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, formatText(*line))
+}
+
+func (a *API) serve(w http.ResponseWriter, r *http.Request, channel, userID string, from, to time.Time) {
+	lines, err := a.store.GetLogLines(channel, userID, from, to)
+	if err != nil {
+		log.Error().Err(err).Str("channel", channel).Msg("Getting log lines")
+		web.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(lines) == 0 {
+		web.WriteError(w, http.StatusNotFound, "no logs found")
+		return
+	}
+
+	query := r.URL.Query()
+	if _, ok := query["reverse"]; ok {
+		for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+			lines[i], lines[j] = lines[j], lines[i]
+		}
+	}
+
+	_, isJSON := query["json"]
+	_, isRaw := query["raw"]
+	switch {
+	case isJSON || query.Get("type") == "json":
+		messages := make([]message, len(lines))
+		for i, line := range lines {
+			messages[i] = newMessage(line)
+		}
+		web.WriteJSON(w, http.StatusOK, map[string]interface{}{"messages": messages})
+	case isRaw || query.Get("type") == "raw":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, line := range lines {
+			fmt.Fprintln(w, line.Raw)
+		}
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, line := range lines {
+			fmt.Fprintln(w, formatText(line))
+		}
+	}
+}
+
+// message is a log line in the JSON format of justlog.
+type message struct {
+	Text        string             `json:"text"`
+	Username    string             `json:"username"`
+	DisplayName string             `json:"displayName"`
+	Channel     string             `json:"channel"`
+	Timestamp   time.Time          `json:"timestamp"`
+	ID          string             `json:"id"`
+	Type        twitch.MessageType `json:"type"`
+	Raw         string             `json:"raw"`
+	Tags        map[string]string  `json:"tags"`
+}
+
+func newMessage(line state.LogLine) message {
+	return message{
+		Text:        line.Text,
+		Username:    line.UserName,
+		DisplayName: line.Tags["display-name"],
+		Channel:     line.Channel,
+		Timestamp:   line.Time.UTC(),
+		ID:          line.Tags["id"],
+		Type:        twitch.PRIVMSG,
+		Raw:         line.Raw,
+		Tags:        line.Tags,
+	}
+}
+
+// formatText formats line like justlog does in plain text.
+func formatText(line state.LogLine) string {
+	return fmt.Sprintf("[%s] #%s %s: %s", line.Time.UTC().Format("2006-01-02 15:04:05"), line.Channel, line.UserName, line.Text)
+}
+
+func parseDate(year, month, day string) (time.Time, bool) {
+	y, errYear := strconv.Atoi(year)
+	m, errMonth := strconv.Atoi(month)
+	d, errDay := strconv.Atoi(day)
+	if errYear != nil || errMonth != nil || errDay != nil || m < 1 || m > 12 || d < 1 || d > 31 {
+		return time.Time{}, false
+	}
+
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC), true
+}
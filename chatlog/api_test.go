@@ -0,0 +1,110 @@
+package chatlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type users map[string]string
+
+func (u users) GetUserByName(name string) (state.User, error) {
+	id, ok := u[name]
+	if !ok {
+		return state.User{}, mongo.ErrNoDocuments
+	}
+	return state.User{ID: id, Name: name}, nil
+}
+
+func TestAPI(t *testing.T) {
+	store, err := NewFiles(t.TempDir(), time.Hour)
+	require.NoError(t, err)
+	defer store.Close()
+
+	day := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, line := range []state.LogLine{
+		{Channel: "chronophylos", UserID: "1", UserName: "someone", Text: "hello", Raw: "raw hello", Time: day},
+		{Channel: "chronophylos", UserID: "2", UserName: "other", Text: "hi", Raw: "raw hi", Time: day.Add(time.Second)},
+		{Channel: "chronophylos", UserID: "1", UserName: "someone", Text: "bye", Raw: "raw bye", Time: day.Add(24 * time.Hour)},
+	} {
+		require.NoError(t, store.AddLogLine(line))
+	}
+
+	api := NewAPI(store, users{"someone": "1", "other": "2"})
+	api.Logged = func(channel string) bool { return channel != "someone" }
+
+	var tests = []struct {
+		path     string
+		status   int
+		body     string
+		location string
+	}{
+		{"/channel/chronophylos/user/someone/2020/5", http.StatusOK,
+			"[2020-05-01 12:00:00] #chronophylos someone: hello\n[2020-05-02 12:00:00] #chronophylos someone: bye\n", ""},
+		{"/channel/chronophylos/userid/1/2020/5?reverse", http.StatusOK,
+			"[2020-05-02 12:00:00] #chronophylos someone: bye\n[2020-05-01 12:00:00] #chronophylos someone: hello\n", ""},
+		{"/channel/chronophylos/user/someone/2020/5?raw", http.StatusOK, "raw hello\nraw bye\n", ""},
+		{"/channel/chronophylos/2020/5/1?type=raw", http.StatusOK, "raw hello\nraw hi\n", ""},
+		{"/channel/chronophylos/user/someone/2020/6", http.StatusNotFound, "", ""},
+		{"/channel/chronophylos/user/nobody/2020/5", http.StatusNotFound, "", ""},
+		{"/channel/chronophylos/user/someone", http.StatusFound, "", "/channel/chronophylos/userid/1/2020/5"},
+		{"/channel/chronophylos?json", http.StatusFound, "", "/channel/chronophylos/2020/5/2?json"},
+		{"/list?channel=chronophylos&user=someone", http.StatusOK, `{"availableLogs":[{"year":"2020","month":"5"}]}` + "\n", ""},
+		{"/list?channel=chronophylos", http.StatusOK,
+			`{"availableLogs":[{"year":"2020","month":"5","day":"2"},{"year":"2020","month":"5","day":"1"}]}` + "\n", ""},
+		{"/list?channel=../..", http.StatusBadRequest, "", ""},
+		{"/list?channel=someone", http.StatusNotFound, "", ""},
+		{"/channel/..%2F..%2Fsecret/2020/5/1", http.StatusBadRequest, "", ""},
+		{"/channel/someone/2020/5/1", http.StatusNotFound, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			assert.Equal(t, test.status, rec.Code)
+			if test.body != "" {
+				assert.Equal(t, test.body, rec.Body.String())
+			}
+			assert.Equal(t, test.location, rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestAPIJSON(t *testing.T) {
+	store, err := NewFiles(t.TempDir(), time.Hour)
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.AddLogLine(state.LogLine{
+		Channel:  "chronophylos",
+		UserID:   "1",
+		UserName: "someone",
+		Text:     "hello",
+		Tags:     map[string]string{"display-name": "Someone", "id": "a"},
+		Time:     time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC),
+	}))
+
+	rec := httptest.NewRecorder()
+	NewAPI(store, users{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/channel/chronophylos/userid/1/2020/5?json", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"messages":[{
+		"text": "hello",
+		"username": "someone",
+		"displayName": "Someone",
+		"channel": "chronophylos",
+		"timestamp": "2020-05-01T12:00:00Z",
+		"id": "a",
+		"type": 1,
+		"raw": "",
+		"tags": {"display-name": "Someone", "id": "a"}
+	}]}`, rec.Body.String())
+}
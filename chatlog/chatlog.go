@@ -0,0 +1,49 @@
+// Package chatlog stores chat messages either in the database or in files
+// rotated daily.
+package chatlog
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/gempir/go-twitch-irc/v2"
+)
+
+// ErrInvalidChannel is returned for channel names twitch would not allow.
+var ErrInvalidChannel = errors.New("invalid channel name")
+
+var channelRe = regexp.MustCompile(`^[a-z0-9_]{1,25}$`)
+
+// ValidChannel reports wheather channel is a valid twitch channel name.
+func ValidChannel(channel string) bool {
+	return channelRe.MatchString(channel)
+}
+
+// Store stores log lines. *state.Client and *Files are stores.
+type Store interface {
+	AddLogLine(line state.LogLine) error
+	// GetLastLogLine and GetRandomLogLine return nil if there is no line.
+	GetLastLogLine(channel, userID string) (*state.LogLine, error)
+	GetRandomLogLine(channel, userID string) (*state.LogLine, error)
+	SearchLogLines(channel, userID, text string, limit int) ([]state.LogLine, error)
+	// GetLogLines and GetLogDays return the lines or days of all users if
+	// userID is empty.
+	GetLogLines(channel, userID string, from, to time.Time) ([]state.LogLine, error)
+	GetLogDays(channel, userID string) ([]time.Time, error)
+	RemoveLogLines(userID string) error
+}
+
+// FromMessage converts msg to a log line.
+func FromMessage(msg *twitch.PrivateMessage) state.LogLine {
+	return state.LogLine{
+		Channel:  msg.Channel,
+		UserID:   msg.User.ID,
+		UserName: msg.User.Name,
+		Text:     msg.Message,
+		Tags:     msg.Tags,
+		Raw:      msg.Raw,
+		Time:     msg.Time,
+	}
+}
@@ -0,0 +1,551 @@
+package chatlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/rs/zerolog/log"
+)
+
+// dayFormat is the name of a log file without extension.
+const dayFormat = "2006-01-02"
+
+// maxLineSize is the size of the longest line read from a log file.
+const maxLineSize = 64 * 1024
+
+// Files stores log lines as JSON in one file per channel and day:
+// <dir>/<channel>/<yyyy-mm-dd>.jsonl. Files older than the retention are
+// removed by Run.
+//
+// Channels are locked separately. Lines are only appended so adding lines
+// and reading files of a channel don't block each other. Only removing lines
+// and pruning lock a channel exclusively.
+type Files struct {
+	dir       string
+	retention time.Duration
+
+	mu       sync.Mutex
+	channels map[string]*channelFiles
+}
+
+// channelFiles are the files of a channel.
+type channelFiles struct {
+	// rw is held for reading while files are read or appended to and for
+	// writing while they are rewritten or removed.
+	rw sync.RWMutex
+
+	// writeMu guards the file lines are appended to.
+	writeMu sync.Mutex
+	day     string
+	file    *os.File
+}
+
+// close closes the file lines are appended to. c.writeMu must be locked.
+func (c *channelFiles) close() error {
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.day, c.file = "", nil
+	return err
+}
+
+// NewFiles creates a file store in dir. It creates dir if needed.
+func NewFiles(dir string, retention time.Duration) (*Files, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+
+	return &Files{
+		dir:       dir,
+		retention: retention,
+		channels:  map[string]*channelFiles{},
+	}, nil
+}
+
+// channel returns the files of channel. It fails if channel is not a valid
+// channel name so it can't be used to reach files outside of f.dir.
+func (f *Files) channel(channel string) (*channelFiles, error) {
+	if !ValidChannel(channel) {
+		return nil, ErrInvalidChannel
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.channels[channel]
+	if !ok {
+		c = &channelFiles{}
+		f.channels[channel] = c
+	}
+	return c, nil
+}
+
+// AddLogLine appends line to the file of its channel and day.
+func (f *Files) AddLogLine(line state.LogLine) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	c, err := f.channel(line.Channel)
+	if err != nil {
+		return err
+	}
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	day := line.Time.UTC().Format(dayFormat)
+
+	if c.file == nil || c.day != day {
+		c.close()
+
+		dir := filepath.Join(f.dir, line.Channel)
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(filepath.Join(dir, day+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+		if err != nil {
+			return err
+		}
+
+		c.day, c.file = day, file
+	}
+
+	// A single write so readers see whole lines
+	_, err = c.file.Write(append(data, '\n'))
+
+	return err
+}
+
+// GetLastLogLine returns the newest line of the user with the twitch ID
+// userID in channel. It returns nil if there is none.
+func (f *Files) GetLastLogLine(channel, userID string) (*state.LogLine, error) {
+	c, err := f.channel(channel)
+	if err != nil {
+		return nil, err
+	}
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	days, err := f.days(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(days) - 1; i >= 0; i-- {
+		var last *state.LogLine
+		err := f.read(channel, days[i], func(line state.LogLine) {
+			if line.UserID == userID {
+				last = &line
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			return last, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetRandomLogLine returns a random line of the user with the twitch ID
+// userID in channel. It returns nil if there is none.
+func (f *Files) GetRandomLogLine(channel, userID string) (*state.LogLine, error) {
+	c, err := f.channel(channel)
+	if err != nil {
+		return nil, err
+	}
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	days, err := f.days(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reservoir sampling so the files are read only once
+	var picked *state.LogLine
+	var n int
+	for _, day := range days {
+		err := f.read(channel, day, func(line state.LogLine) {
+			if line.UserID != userID {
+				return
+			}
+			n++
+			if rand.Intn(n) == 0 {
+				picked = &line
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return picked, nil
+}
+
+// SearchLogLines returns up to limit lines of the user with the twitch ID
+// userID in channel containing text, newest first. Case is ignored.
+func (f *Files) SearchLogLines(channel, userID, text string, limit int) ([]state.LogLine, error) {
+	c, err := f.channel(channel)
+	if err != nil {
+		return nil, err
+	}
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	lines := []state.LogLine{}
+	text = strings.ToLower(text)
+
+	days, err := f.days(channel)
+	if err != nil {
+		return lines, err
+	}
+
+	for i := len(days) - 1; i >= 0 && len(lines) < limit; i-- {
+		var found []state.LogLine
+		err := f.read(channel, days[i], func(line state.LogLine) {
+			if line.UserID == userID && strings.Contains(strings.ToLower(line.Text), text) {
+				found = append(found, line)
+			}
+		})
+		if err != nil {
+			return lines, err
+		}
+
+		for j := len(found) - 1; j >= 0 && len(lines) < limit; j-- {
+			lines = append(lines, found[j])
+		}
+	}
+
+	return lines, nil
+}
+
+// GetLogLines returns the lines in channel sent at or after from and before
+// to, oldest first. If userID is not empty only lines of the user with this
+// twitch ID are returned.
+func (f *Files) GetLogLines(channel, userID string, from, to time.Time) ([]state.LogLine, error) {
+	c, err := f.channel(channel)
+	if err != nil {
+		return nil, err
+	}
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	lines := []state.LogLine{}
+
+	days, err := f.days(channel)
+	if err != nil {
+		return lines, err
+	}
+
+	first := from.UTC().Format(dayFormat)
+	last := to.UTC().Format(dayFormat)
+	for _, day := range days {
+		if day < first || day > last {
+			continue
+		}
+
+		err := f.read(channel, day, func(line state.LogLine) {
+			if (userID == "" || line.UserID == userID) && !line.Time.Before(from) && line.Time.Before(to) {
+				lines = append(lines, line)
+			}
+		})
+		if err != nil {
+			return lines, err
+		}
+	}
+
+	return lines, nil
+}
+
+// GetLogDays returns the days in UTC with lines in channel, oldest first. If
+// userID is not empty only days with lines of the user with this twitch ID
+// are returned.
+func (f *Files) GetLogDays(channel, userID string) ([]time.Time, error) {
+	c, err := f.channel(channel)
+	if err != nil {
+		return nil, err
+	}
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	list := []time.Time{}
+
+	days, err := f.days(channel)
+	if err != nil {
+		return list, err
+	}
+
+	for _, day := range days {
+		if userID != "" {
+			var found bool
+			err := f.read(channel, day, func(line state.LogLine) {
+				found = found || line.UserID == userID
+			})
+			if err != nil {
+				return list, err
+			}
+			if !found {
+				continue
+			}
+		}
+
+		t, err := time.Parse(dayFormat, day)
+		if err != nil {
+			continue
+		}
+		list = append(list, t)
+	}
+
+	return list, nil
+}
+
+// RemoveLogLines removes all lines of the user with the twitch ID userID by
+// rewriting every file. Only the channel being rewritten is locked.
+func (f *Files) RemoveLogLines(userID string) error {
+	channels, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		if !channel.IsDir() || !ValidChannel(channel.Name()) {
+			continue
+		}
+
+		if err := f.removeLogLines(channel.Name(), userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *Files) removeLogLines(channel, userID string) error {
+	c, err := f.channel(channel)
+	if err != nil {
+		return err
+	}
+	c.rw.Lock()
+	defer c.rw.Unlock()
+
+	c.writeMu.Lock()
+	c.close()
+	c.writeMu.Unlock()
+
+	days, err := f.days(channel)
+	if err != nil {
+		return err
+	}
+
+	for _, day := range days {
+		if err := f.rewrite(channel, day, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Prune removes files of days that ended before now minus the retention.
+func (f *Files) Prune(now time.Time) error {
+	channels, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+
+	oldest := now.Add(-f.retention).UTC().Format(dayFormat)
+	for _, channel := range channels {
+		if !channel.IsDir() || !ValidChannel(channel.Name()) {
+			continue
+		}
+
+		if err := f.prune(channel.Name(), oldest); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prune removes the files of channel of days before oldest.
+func (f *Files) prune(channel, oldest string) error {
+	c, err := f.channel(channel)
+	if err != nil {
+		return err
+	}
+	c.rw.Lock()
+	defer c.rw.Unlock()
+
+	days, err := f.days(channel)
+	if err != nil {
+		return err
+	}
+
+	for _, day := range days {
+		if day >= oldest {
+			break
+		}
+		c.writeMu.Lock()
+		if c.day == day {
+			c.close()
+		}
+		c.writeMu.Unlock()
+		if err := os.Remove(f.path(channel, day)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run prunes old files every hour until ctx is done.
+func (f *Files) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if err := f.Prune(time.Now()); err != nil {
+			log.Error().
+				Err(err).
+				Str("dir", f.dir).
+				Msg("Pruning chat logs")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes all open files. Adding lines afterwards opens them again.
+func (f *Files) Close() error {
+	f.mu.Lock()
+	channels := make([]*channelFiles, 0, len(f.channels))
+	for _, c := range f.channels {
+		channels = append(channels, c)
+	}
+	f.mu.Unlock()
+
+	var err error
+	for _, c := range channels {
+		c.writeMu.Lock()
+		if e := c.close(); e != nil {
+			err = e
+		}
+		c.writeMu.Unlock()
+	}
+	return err
+}
+
+func (f *Files) path(channel, day string) string {
+	return filepath.Join(f.dir, channel, day+".jsonl")
+}
+
+// days returns the days with a log file of channel, oldest first.
+func (f *Files) days(channel string) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(f.dir, channel))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var days []string
+	for _, file := range files {
+		if day := strings.TrimSuffix(file.Name(), ".jsonl"); day != file.Name() {
+			days = append(days, day)
+		}
+	}
+	sort.Strings(days)
+
+	return days, nil
+}
+
+// read calls fn for every line in the file of channel and day in order.
+func (f *Files) read(channel, day string, fn func(line state.LogLine)) error {
+	file, err := os.Open(f.path(channel, day))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 4096), maxLineSize)
+	scanner.Split(scanWholeLines)
+	for scanner.Scan() {
+		var line state.LogLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("reading %s: %v", file.Name(), err)
+		}
+		fn(line)
+	}
+
+	return scanner.Err()
+}
+
+// scanWholeLines splits lines like bufio.ScanLines but drops a last line
+// without newline. It is still being appended to.
+func scanWholeLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, bytes.TrimSuffix(data[:i], []byte{'\r'}), nil
+	}
+	if atEOF {
+		return len(data), nil, nil
+	}
+	return 0, nil, nil
+}
+
+// rewrite removes the lines of userID from the file of channel and day.
+func (f *Files) rewrite(channel, day, userID string) error {
+	path := f.path(channel, day)
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), day+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	var removed bool
+	err = f.read(channel, day, func(line state.LogLine) {
+		if line.UserID == userID {
+			removed = true
+			return
+		}
+		data, _ := json.Marshal(line)
+		w.Write(append(data, '\n'))
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err != nil || !removed {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,107 @@
+package chatlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewFiles(dir, 48*time.Hour)
+	require.NoError(t, err)
+	defer f.Close()
+
+	day := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	lines := []state.LogLine{
+		{Channel: "chronophylos", UserID: "1", Text: "hello", Time: day},
+		{Channel: "chronophylos", UserID: "2", Text: "Hello there", Time: day.Add(time.Minute)},
+		{Channel: "chronophylos", UserID: "1", Text: "HELLO again", Time: day.Add(24 * time.Hour)},
+		{Channel: "chronophylos", UserID: "1", Text: "bye", Time: day.Add(25 * time.Hour)},
+		{Channel: "someone", UserID: "1", Text: "hello", Time: day},
+	}
+	for _, line := range lines {
+		require.NoError(t, f.AddLogLine(line))
+	}
+
+	last, err := f.GetLastLogLine("chronophylos", "1")
+	require.NoError(t, err)
+	assert.Equal(t, "bye", last.Text)
+
+	last, err = f.GetLastLogLine("chronophylos", "3")
+	require.NoError(t, err)
+	assert.Nil(t, last)
+
+	random, err := f.GetRandomLogLine("chronophylos", "2")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello there", random.Text)
+
+	found, err := f.SearchLogLines("chronophylos", "1", "hello", 10)
+	require.NoError(t, err)
+	if assert.Len(t, found, 2) {
+		assert.Equal(t, "HELLO again", found[0].Text)
+		assert.Equal(t, "hello", found[1].Text)
+	}
+
+	require.NoError(t, f.RemoveLogLines("1"))
+	found, err = f.SearchLogLines("chronophylos", "1", "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+	random, err = f.GetRandomLogLine("chronophylos", "2")
+	require.NoError(t, err)
+	assert.NotNil(t, random)
+
+	for _, channel := range []string{"..", "../chronophylos", "Chronophylos", ""} {
+		_, err = f.GetLogLines(channel, "", day, day.Add(time.Hour))
+		assert.Equal(t, ErrInvalidChannel, err, channel)
+		assert.Equal(t, ErrInvalidChannel, f.AddLogLine(state.LogLine{Channel: channel, Time: day}), channel)
+	}
+
+	require.NoError(t, f.Prune(day.Add(72*time.Hour)))
+	_, err = os.Stat(filepath.Join(dir, "chronophylos", "2020-05-01.jsonl"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "chronophylos", "2020-05-02.jsonl"))
+	assert.NoError(t, err)
+}
+
+func TestFilesLocking(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewFiles(dir, 48*time.Hour)
+	require.NoError(t, err)
+	defer f.Close()
+
+	day := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, f.AddLogLine(state.LogLine{Channel: "chronophylos", UserID: "1", Text: "hello", Time: day}))
+
+	// A long read of a channel does not block adding lines
+	c, err := f.channel("chronophylos")
+	require.NoError(t, err)
+	c.rw.RLock()
+	done := make(chan error, 1)
+	go func() {
+		done <- f.AddLogLine(state.LogLine{Channel: "chronophylos", UserID: "1", Text: "bye", Time: day})
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("adding a line blocked")
+	}
+	c.rw.RUnlock()
+
+	// A line still being written is not read
+	file, err := os.OpenFile(filepath.Join(dir, "chronophylos", "2020-05-01.jsonl"), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"Channel":"chrono`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	last, err := f.GetLastLogLine("chronophylos", "1")
+	require.NoError(t, err)
+	assert.Equal(t, "bye", last.Text)
+}
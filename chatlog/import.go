@@ -0,0 +1,86 @@
+package chatlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gempir/go-twitch-irc/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// Import adds the chat messages of a justlog archive in dir to store and
+// returns how many were imported. Only the channel logs
+// (<channel id>/<year>/<month>/<day>/channel.txt[.gz]) are read since the
+// user logs contain the same messages. Messages are skipped unless logged
+// reports that their channel is logged and their sender did not opt out.
+// Importing the same archive twice imports every message twice.
+func Import(store Store, dir string, logged func(channel, userID string) bool) (int, error) {
+	var count int
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || (info.Name() != "channel.txt" && info.Name() != "channel.txt.gz") {
+			return nil
+		}
+
+		n, skipped, err := importFile(store, path, logged)
+		count += n
+		if err != nil {
+			return err
+		}
+
+		log.Info().
+			Str("file", path).
+			Int("messages", n).
+			Int("skipped", skipped).
+			Msg("Imported log file")
+
+		return nil
+	})
+
+	return count, err
+}
+
+func importFile(store Store, path string, logged func(channel, userID string) bool) (count, skipped int, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if filepath.Ext(path) == ".gz" {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), maxLineSize)
+	for scanner.Scan() {
+		msg, ok := twitch.ParseMessage(scanner.Text()).(*twitch.PrivateMessage)
+		if !ok {
+			continue
+		}
+
+		if !logged(msg.Channel, msg.User.ID) {
+			skipped++
+			continue
+		}
+
+		if err := store.AddLogLine(FromMessage(msg)); err != nil {
+			return count, skipped, err
+		}
+		count++
+	}
+
+	return count, skipped, scanner.Err()
+}
@@ -0,0 +1,58 @@
+package chatlog
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rawLines = `@badge-info=;badges=;color=#FF0000;display-name=Someone;emotes=;flags=;id=a;mod=0;room-id=54946241;subscriber=0;tmi-sent-ts=1588334400000;turbo=0;user-id=1;user-type= :someone!someone@someone.tmi.twitch.tv PRIVMSG #chronophylos :hello there
+@ban-duration=60;room-id=54946241;target-user-id=1;tmi-sent-ts=1588334401000 :tmi.twitch.tv CLEARCHAT #chronophylos :someone
+@badge-info=;badges=;color=;display-name=Other;emotes=;flags=;id=b;mod=0;room-id=54946241;subscriber=0;tmi-sent-ts=1588334402000;turbo=0;user-id=2;user-type= :other!other@other.tmi.twitch.tv PRIVMSG #chronophylos :hi
+`
+
+func TestImport(t *testing.T) {
+	archive := t.TempDir()
+	day := filepath.Join(archive, "54946241", "2020", "5", "1")
+	require.NoError(t, os.MkdirAll(day, 0755))
+
+	file, err := os.Create(filepath.Join(day, "channel.txt.gz"))
+	require.NoError(t, err)
+	gz := gzip.NewWriter(file)
+	_, err = gz.Write([]byte(rawLines))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, file.Close())
+
+	// User logs are skipped
+	require.NoError(t, os.WriteFile(filepath.Join(archive, "54946241", "2020", "5", "1.txt"), []byte(rawLines), 0644))
+
+	store, err := NewFiles(t.TempDir(), time.Hour)
+	require.NoError(t, err)
+	defer store.Close()
+
+	// The user with the ID 2 opted out
+	n, err := Import(store, archive, func(channel, userID string) bool {
+		return channel == "chronophylos" && userID != "2"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	line, err := store.GetLastLogLine("chronophylos", "2")
+	require.NoError(t, err)
+	assert.Nil(t, line, "opted out users are not imported")
+
+	line, err = store.GetLastLogLine("chronophylos", "1")
+	require.NoError(t, err)
+	if assert.NotNil(t, line) {
+		assert.Equal(t, "hello there", line.Text)
+		assert.Equal(t, "someone", line.UserName)
+		assert.Equal(t, "Someone", line.Tags["display-name"])
+		assert.True(t, line.Time.Equal(time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)))
+	}
+}
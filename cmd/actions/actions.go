@@ -2,13 +2,30 @@ package actions
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+	"sort"
+	"sync"
 	"time"
 )
 
 type Options struct {
-	Name             string
-	Re               *regexp.Regexp
+	Name string
+
+	// Re triggers the action if it matches a message.
+	Re *regexp.Regexp
+	// Cmd triggers the action if the message invokes the command. Either Re
+	// or Cmd must be set.
+	Cmd *Command
+
+	// Description explains what the action does in a sentence or two.
+	Description string
+	// Usage shows how to trigger the action. It is generated from Cmd if
+	// empty.
+	Usage string
+	// Examples are messages that trigger the action.
+	Examples []string
+
 	Sleepless        bool
 	Perm             Permission
 	Disabled         bool
@@ -26,40 +43,74 @@ type Action interface {
 
 type Actions []Action
 
-var actions = Actions{
-	newVersionAction(),
-	newSleepAction(),
-	newWakeAction(),
-	newJoinAction(),
-	newLeaveAction(),
-	newLurkAction(),
-	newDebugAction(),
-	newVoicemailAction(),
-	newPatscheckAction(),
-	newPatschAction(),
-	newVanishReplyAction(),
-	newCircumflexAction(),
-	newPingAction(),
-	newRateAction(),
-	newWeatherAction1(),
-	newWeatherAction2(),
-	newLocationAction(),
-	newErDrAction(),
-	newHelloAction(),
-	newHelloStirnbotAction(),
-	newMarcsAgeAction(),
-	newMaxikingsAgeAction(),
-	newTimeAction(),
-	newScambotAction(),
-	newReuploadAction(),
-	newFillAction(),
-	newSuicideAction(),
-	newTrueAction(),
-	newHeartAction(),
-	newMathAction(),
+// Priorities for Register. Actions with a lower priority are run first. Values
+// in between are fine too.
+const (
+	// PriorityHigh is for actions that control the bot itself.
+	PriorityHigh = 100
+	// PriorityNormal is for commands.
+	PriorityNormal = 500
+	// PriorityLow is for actions reacting to arbitrary messages.
+	PriorityLow = 900
+)
+
+type registration struct {
+	priority int
+	action   Action
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []registration
+)
+
+// Register makes an action available to the manager. Actions are run in the
+// order of their priority; actions with the same priority run in the order
+// they were registered in. Register is meant to be called from init and
+// panics if the action is malformed or its name is already taken.
+func Register(priority int, a Action) {
+	if err := Check(a); err != nil {
+		panic(fmt.Sprintf("actions: malformed action %T: %v", a, err))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name := a.GetOptions().Name
+	for _, r := range registry {
+		if r.action.GetOptions().Name == name {
+			panic(fmt.Sprintf("actions: Register called twice for action %s", name))
+		}
+	}
+
+	registry = append(registry, registration{priority: priority, action: a})
+	sort.SliceStable(registry, func(i, j int) bool {
+		return registry[i].priority < registry[j].priority
+	})
 }
 
-func GetAll() Actions { return actions }
+// GetAll returns all registered actions ordered by their priority.
+func GetAll() Actions {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	all := make(Actions, len(registry))
+	for i, r := range registry {
+		all[i] = r.action
+	}
+	return all
+}
+
+// UsageFor returns the usage of the action with options opt for prefix.
+func UsageFor(opt *Options, prefix string) string {
+	if opt.Usage != "" {
+		return opt.Usage
+	}
+	if opt.Cmd != nil {
+		return opt.Cmd.Usage(prefix)
+	}
+	return ""
+}
 
 func Check(a Action) error {
 	opt := a.GetOptions()
@@ -68,8 +119,24 @@ func Check(a Action) error {
 		return errors.New("required field Name is empty")
 	}
 
-	if opt.Re == nil {
-		return errors.New("required field Re is nil")
+	if opt.Re == nil && opt.Cmd == nil {
+		return errors.New("either field Re or Cmd is required")
+	}
+
+	if opt.Re != nil && opt.Cmd != nil {
+		return errors.New("only one of the fields Re and Cmd may be set")
+	}
+
+	if opt.Cmd != nil {
+		if opt.Cmd.Name == "" {
+			return errors.New("required field Cmd.Name is empty")
+		}
+
+		for i, arg := range opt.Cmd.Args {
+			if arg.Type == ArgRest && i != len(opt.Cmd.Args)-1 {
+				return errors.New("only the last argument may be of type ArgRest")
+			}
+		}
 	}
 
 	if opt.Perm < Everyone || opt.Perm > Owner {
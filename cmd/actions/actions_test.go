@@ -0,0 +1,39 @@
+package actions
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAction struct {
+	options *Options
+}
+
+func (a testAction) GetOptions() *Options { return a.options }
+func (a testAction) Run(e *Event) error   { return nil }
+
+func newTestAction(name string) testAction {
+	return testAction{options: &Options{Name: name, Re: regexp.MustCompile(name)}}
+}
+
+func TestRegister(t *testing.T) {
+	saved := registry
+	defer func() { registry = saved }()
+	registry = nil
+
+	Register(PriorityLow, newTestAction("low"))
+	Register(PriorityHigh, newTestAction("high"))
+	Register(PriorityNormal, newTestAction("normal1"))
+	Register(PriorityNormal, newTestAction("normal2"))
+
+	names := []string{}
+	for _, action := range GetAll() {
+		names = append(names, action.GetOptions().Name)
+	}
+	assert.Equal(t, []string{"high", "normal1", "normal2", "low"}, names)
+
+	assert.Panics(t, func() { Register(PriorityNormal, newTestAction("low")) }, "duplicate name")
+	assert.Panics(t, func() { Register(PriorityNormal, testAction{options: &Options{Name: "empty"}}) }, "no trigger")
+}
@@ -1,9 +1,10 @@
 package actions
 
-import (
-	"fmt"
-	"regexp"
-)
+func init() {
+	Register(PriorityHigh, newJoinAction())
+	Register(PriorityHigh, newLeaveAction())
+	Register(PriorityHigh, newLurkAction())
+}
 
 type joinAction struct {
 	options *Options
@@ -13,8 +14,13 @@ func newJoinAction() *joinAction {
 	return &joinAction{
 		options: &Options{
 			Name: "admin.join",
-			Re:   regexp.MustCompile(`(?i)^~join( (\w+))?`),
-			Perm: Owner,
+			Cmd: &Command{
+				Name: "join",
+				Args: []Arg{{Name: "channel", Type: ArgUser, Optional: true}},
+			},
+			Perm:        Owner,
+			Description: "Joins your channel or the given channel. Only works in the bots channel.",
+			Examples:    []string{"~join", "~join chronophylos"},
 		},
 	}
 }
@@ -29,12 +35,10 @@ func (a joinAction) Run(e *Event) error {
 		return &notInBotChannelError{channel: e.Msg.Channel}
 	}
 
-	var channel string
-	if e.Match[2] == "" {
+	channel := e.Args.String("channel")
+	if channel == "" {
 		// No channel was specified; join the senders channel.
 		channel = e.Msg.User.Name
-	} else {
-		channel = e.Match[2]
 	}
 
 	e.Log.Info().
@@ -44,10 +48,7 @@ func (a joinAction) Run(e *Event) error {
 	e.Twitch.Join(channel)
 	e.State.JoinChannel(channel, true)
 
-	e.Say(fmt.Sprintf(
-		"I joined %s. Type `~leave %s` and I'll leave.",
-		channel, channel,
-	))
+	e.Say(e.T("admin.join", channel, channel))
 
 	return nil
 }
@@ -60,8 +61,13 @@ func newLeaveAction() *leaveAction {
 	return &leaveAction{
 		options: &Options{
 			Name: "admin.leave",
-			Re:   regexp.MustCompile(`(?i)^~leave( (\w+))?`),
-			Perm: Owner,
+			Cmd: &Command{
+				Name: "leave",
+				Args: []Arg{{Name: "channel", Type: ArgUser, Optional: true}},
+			},
+			Perm:        Owner,
+			Description: "Leaves your channel or the given channel. Only works in the bots channel.",
+			Examples:    []string{"~leave chronophylos"},
 		},
 	}
 }
@@ -76,12 +82,10 @@ func (a leaveAction) Run(e *Event) error {
 		return &notInBotChannelError{channel: e.Msg.Channel}
 	}
 
-	var channel string
-	if e.Match[2] == "" {
-		// No channel was specified; join the senders channel.
+	channel := e.Args.String("channel")
+	if channel == "" {
+		// No channel was specified; leave the senders channel.
 		channel = e.Msg.User.Name
-	} else {
-		channel = e.Match[2]
 	}
 
 	e.Log.Info().
@@ -91,7 +95,7 @@ func (a leaveAction) Run(e *Event) error {
 	e.Twitch.Depart(channel)
 	e.State.JoinChannel(channel, false)
 
-	e.Say("ppPoof")
+	e.Say(e.T("admin.leave"))
 
 	return nil
 }
@@ -104,8 +108,13 @@ func newLurkAction() *lurkAction {
 	return &lurkAction{
 		options: &Options{
 			Name: "admin.lurk",
-			Re:   regexp.MustCompile(`(?i)^~lurk (\w+)`),
-			Perm: Owner,
+			Cmd: &Command{
+				Name: "lurk",
+				Args: []Arg{{Name: "channel", Type: ArgUser}},
+			},
+			Perm:        Owner,
+			Description: "Joins a channel without ever replying there. Only works in the bots channel.",
+			Examples:    []string{"~lurk chronophylos"},
 		},
 	}
 }
@@ -119,7 +128,7 @@ func (a lurkAction) Run(e *Event) error {
 		return &notInBotChannelError{channel: e.Msg.Channel}
 	}
 
-	channel := e.Match[2]
+	channel := e.Args.String("channel")
 
 	e.Twitch.Join(channel)
 	e.State.SetLurking(channel, true)
@@ -129,7 +138,7 @@ func (a lurkAction) Run(e *Event) error {
 		Str("new-channel", channel).
 		Msg("Lurking in new channel")
 
-	e.Say(fmt.Sprintf("I'm lurking in %s now.", channel))
+	e.Say(e.T("admin.lurk", channel))
 
 	return nil
 }
@@ -0,0 +1,292 @@
+package actions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/chronophylos/chb3/stats"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	Register(PriorityNormal, newStatsAction())
+	Register(PriorityNormal, newTopChattersAction())
+	Register(PriorityNormal, newChannelStatsAction())
+}
+
+// TopChatters is the number of users listed by ~topchatters.
+const TopChatters = 5
+
+// pendingStats returns the counts of the channel of e since since that were
+// not written to the database yet. Replies add them to the stored counts.
+func pendingStats(e *Event, since time.Time) ([]state.UserStats, []state.ChannelStats) {
+	if e.Stats == nil {
+		return nil, nil
+	}
+
+	users, channels := e.Stats.Pending(e.Msg.Channel)
+
+	var recentUsers []state.UserStats
+	for _, s := range users {
+		if !s.Day.Before(since) {
+			recentUsers = append(recentUsers, s)
+		}
+	}
+	var recentChannels []state.ChannelStats
+	for _, s := range channels {
+		if !s.Hour.Before(since) {
+			recentChannels = append(recentChannels, s)
+		}
+	}
+	return recentUsers, recentChannels
+}
+
+// userIDs returns the distinct twitch IDs of the users in list.
+func userIDs(list []state.UserStats) []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, s := range list {
+		if !seen[s.UserID] {
+			seen[s.UserID] = true
+			ids = append(ids, s.UserID)
+		}
+	}
+	return ids
+}
+
+// mergeChatters returns the limit users with the most messages of the
+// stored top chatters, the stored counts of the pending users and the
+// pending counts, most messages first.
+func mergeChatters(top, stored, pending []state.UserStats, limit int) []state.UserStats {
+	byID := map[string]*state.UserStats{}
+	for _, list := range [][]state.UserStats{top, stored} {
+		for _, s := range list {
+			s := s
+			byID[s.UserID] = &s
+		}
+	}
+	for _, s := range pending {
+		if merged, ok := byID[s.UserID]; ok {
+			merged.Messages += s.Messages
+			merged.UserName = s.UserName
+			continue
+		}
+		s := s
+		s.Day = time.Time{}
+		byID[s.UserID] = &s
+	}
+
+	list := make([]state.UserStats, 0, len(byID))
+	for _, s := range byID {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Messages != list[j].Messages {
+			return list[i].Messages > list[j].Messages
+		}
+		return list[i].UserName < list[j].UserName
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+type statsAction struct {
+	options *Options
+}
+
+func newStatsAction() *statsAction {
+	return &statsAction{
+		options: &Options{
+			Name: "stats",
+			Cmd: &Command{
+				Name: "stats",
+				Args: []Arg{{Name: "user", Type: ArgUser, Optional: true}},
+			},
+			Description: "Shows how many messages you or a user wrote in this channel today, in the last seven days and in total.",
+			Examples:    []string{"~stats", "~stats someone"},
+		},
+	}
+}
+
+func (a statsAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a statsAction) Run(e *Event) error {
+	id, name := e.Msg.User.ID, e.Msg.User.Name
+	if e.Args.Has("user") {
+		name = e.Args.String("user")
+
+		user, err := e.State.GetUserByName(name)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				e.Say(e.T("stats.unknown", name))
+				return nil
+			}
+			return fmt.Errorf("getting user: %v", err)
+		}
+		id = user.ID
+	}
+
+	list, err := e.State.GetUserStats(e.Msg.Channel, id)
+	if err != nil {
+		return fmt.Errorf("getting user stats: %v", err)
+	}
+	pending, _ := pendingStats(e, time.Time{})
+	for _, s := range pending {
+		if s.UserID == id {
+			list = append(list, s)
+		}
+	}
+
+	today := stats.Day(e.Msg.Time)
+	week := today.AddDate(0, 0, -6)
+
+	var day, last7, total int
+	for _, s := range list {
+		total += s.Messages
+		if !s.Day.Before(week) {
+			last7 += s.Messages
+		}
+		if s.Day.Equal(today) {
+			day += s.Messages
+		}
+	}
+
+	e.Say(e.T("stats.user", name, day, last7, total))
+
+	return nil
+}
+
+type topChattersAction struct {
+	options *Options
+}
+
+func newTopChattersAction() *topChattersAction {
+	return &topChattersAction{
+		options: &Options{
+			Name: "topchatters",
+			Cmd: &Command{
+				Name: "topchatters",
+				Args: []Arg{{Name: "period", Type: ArgWord, Optional: true}},
+			},
+			Usage:       "~topchatters [day|week|all]",
+			Description: "Shows the users with the most messages in this channel today, in the last seven days or ever.",
+			Examples:    []string{"~topchatters", "~topchatters week"},
+		},
+	}
+}
+
+func (a topChattersAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a topChattersAction) Run(e *Event) error {
+	period := strings.ToLower(e.Args.String("period"))
+
+	today := stats.Day(e.Msg.Time)
+	var since time.Time
+	switch period {
+	case "", "day", "today":
+		period = "day"
+		since = today
+	case "week":
+		since = today.AddDate(0, 0, -6)
+	case "all":
+	default:
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	top, err := e.State.GetTopChatters(e.Msg.Channel, since, TopChatters)
+	if err != nil {
+		return fmt.Errorf("getting top chatters: %v", err)
+	}
+	// Users with pending counts may pass the stored top chatters so their
+	// stored counts are needed too
+	pending, _ := pendingStats(e, since)
+	stored, err := e.State.GetChatters(e.Msg.Channel, since, userIDs(pending))
+	if err != nil {
+		return fmt.Errorf("getting chatters: %v", err)
+	}
+	list := mergeChatters(top, stored, pending, TopChatters)
+	if len(list) == 0 {
+		e.Say(e.T("stats.empty"))
+		return nil
+	}
+
+	strs := make([]string, len(list))
+	for i, s := range list {
+		strs[i] = fmt.Sprintf("%s (%d)", s.UserName, s.Messages)
+	}
+
+	e.Say(e.T("stats.top."+period, strings.Join(strs, ", ")))
+
+	return nil
+}
+
+type channelStatsAction struct {
+	options *Options
+}
+
+func newChannelStatsAction() *channelStatsAction {
+	return &channelStatsAction{
+		options: &Options{
+			Name: "channelstats",
+			Cmd: &Command{
+				Name: "channelstats",
+			},
+			Description: "Shows how many messages were written in this channel today and in the last hour and how many users wrote them.",
+			Examples:    []string{"~channelstats"},
+		},
+	}
+}
+
+func (a channelStatsAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a channelStatsAction) Run(e *Event) error {
+	today := stats.Day(e.Msg.Time)
+	hour := e.Msg.Time.UTC().Truncate(time.Hour)
+
+	list, err := e.State.GetChannelStats(e.Msg.Channel, today)
+	if err != nil {
+		return fmt.Errorf("getting channel stats: %v", err)
+	}
+	chatters, err := e.State.CountChatters(e.Msg.Channel, today)
+	if err != nil {
+		return fmt.Errorf("counting chatters: %v", err)
+	}
+
+	// Pending users without stored messages today are not counted yet
+	pendingUsers, pendingChannels := pendingStats(e, today)
+	ids := userIDs(pendingUsers)
+	stored, err := e.State.GetChatters(e.Msg.Channel, today, ids)
+	if err != nil {
+		return fmt.Errorf("getting chatters: %v", err)
+	}
+	chatters += len(ids) - len(stored)
+	list = append(list, pendingChannels...)
+
+	var messages, lastHour int
+	for _, s := range list {
+		messages += s.Messages
+		if s.Hour.Equal(hour) {
+			lastHour += s.Messages
+		}
+	}
+
+	// Average over the hours of today including the current one
+	hours := int(hour.Sub(today)/time.Hour) + 1
+	perHour := float64(messages) / float64(hours)
+
+	e.Say(e.T("stats.channel", messages, chatters, lastHour, perHour))
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeChatters(t *testing.T) {
+	top := []state.UserStats{
+		{UserID: "1", UserName: "first", Messages: 10},
+		{UserID: "2", UserName: "second", Messages: 8},
+	}
+	stored := []state.UserStats{
+		{UserID: "2", UserName: "second", Messages: 8},
+		{UserID: "3", UserName: "third", Messages: 5},
+	}
+	pending := []state.UserStats{
+		{UserID: "3", UserName: "renamed", Messages: 6},
+		{UserID: "4", UserName: "new", Messages: 1},
+	}
+
+	assert.Equal(t, []state.UserStats{
+		{UserID: "3", UserName: "renamed", Messages: 11},
+		{UserID: "1", UserName: "first", Messages: 10},
+		{UserID: "2", UserName: "second", Messages: 8},
+	}, mergeChatters(top, stored, pending, 3))
+}
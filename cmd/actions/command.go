@@ -0,0 +1,232 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPrefix is used for commands in channels that did not set their own
+// prefix.
+const DefaultPrefix = "~"
+
+// ArgType is the type of a command argument.
+type ArgType int
+
+// Possible values for ArgType.
+const (
+	// ArgWord is a single word.
+	ArgWord ArgType = iota
+	// ArgUser is a twitch username. A leading @ is removed and the name is
+	// lowercased.
+	ArgUser
+	// ArgInt is a whole number.
+	ArgInt
+	// ArgDuration is a duration like 10m or 1h30m. Days (d) and weeks (w)
+	// are allowed as well and plain numbers are read as seconds.
+	ArgDuration
+	// ArgRest consumes the rest of the message. It must be the last argument.
+	ArgRest
+)
+
+// Arg declares a single argument of a command.
+type Arg struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+}
+
+// Command describes how an action is invoked as a command, eg. `~rate <what>`.
+type Command struct {
+	Name    string
+	Aliases []string
+	Args    []Arg
+}
+
+// Matches reports wheather name is the name or one of the aliases of c.
+func (c *Command) Matches(name string) bool {
+	name = strings.ToLower(name)
+
+	if name == c.Name {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if name == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage returns a short usage string like `~join [channel]`.
+func (c *Command) Usage(prefix string) string {
+	var b strings.Builder
+
+	b.WriteString(prefix)
+	b.WriteString(c.Name)
+
+	for _, arg := range c.Args {
+		name := arg.Name
+		if arg.Type == ArgRest {
+			name += "…"
+		}
+
+		if arg.Optional {
+			b.WriteString(" [" + name + "]")
+		} else {
+			b.WriteString(" <" + name + ">")
+		}
+	}
+
+	return b.String()
+}
+
+// Parse parses the arguments in text according to c.Args. Commands without
+// Args ignore text like `~ping foo`.
+func (c *Command) Parse(text string) (Args, error) {
+	args := Args{}
+	text = strings.TrimSpace(text)
+
+	for _, arg := range c.Args {
+		if text == "" {
+			if !arg.Optional {
+				return args, fmt.Errorf("missing argument %s", arg.Name)
+			}
+			continue
+		}
+
+		var field string
+		if arg.Type == ArgRest {
+			field, text = text, ""
+		} else {
+			field, text = nextField(text)
+		}
+
+		value, err := parseArg(arg.Type, field)
+		if err != nil {
+			return args, fmt.Errorf("argument %s: %v", arg.Name, err)
+		}
+		args[arg.Name] = value
+	}
+
+	if text != "" && len(c.Args) > 0 {
+		return args, errors.New("too many arguments")
+	}
+
+	return args, nil
+}
+
+func nextField(text string) (string, string) {
+	i := strings.IndexAny(text, " \t")
+	if i < 0 {
+		return text, ""
+	}
+	return text[:i], strings.TrimSpace(text[i:])
+}
+
+func parseArg(t ArgType, field string) (interface{}, error) {
+	switch t {
+	case ArgUser:
+		name := strings.ToLower(strings.TrimPrefix(field, "@"))
+		if name == "" {
+			return nil, errors.New("empty username")
+		}
+		return name, nil
+	case ArgInt:
+		return strconv.Atoi(field)
+	case ArgDuration:
+		return ParseDuration(field)
+	default:
+		return field, nil
+	}
+}
+
+// hasDurationUnit reports wheather s ends with the unit of a duration. Bare
+// numbers are seconds for ParseDuration but are ambiguous in free text.
+func hasDurationUnit(s string) bool {
+	return s != "" && strings.ContainsAny(s[len(s)-1:], "smhdw")
+}
+
+// ParseDuration parses s like time.ParseDuration but also allows days (d),
+// weeks (w) and plain numbers which are read as seconds.
+func ParseDuration(s string) (time.Duration, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+
+	var d time.Duration
+	for _, unit := range []struct {
+		suffix string
+		factor time.Duration
+	}{
+		{"w", 7 * 24 * time.Hour},
+		{"d", 24 * time.Hour},
+	} {
+		i := strings.Index(s, unit.suffix)
+		if i < 0 {
+			continue
+		}
+
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+		d += time.Duration(n) * unit.factor
+		s = s[i+1:]
+	}
+
+	if s == "" {
+		return d, nil
+	}
+
+	rest, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+
+	return d + rest, nil
+}
+
+// ParseInvocation splits message into the command name and the remaining text
+// if message starts with prefix. The name is lowercased.
+func ParseInvocation(prefix, message string) (name, rest string, ok bool) {
+	if prefix == "" || !strings.HasPrefix(message, prefix) {
+		return "", "", false
+	}
+
+	name, rest = nextField(strings.TrimPrefix(message, prefix))
+	if name == "" {
+		return "", "", false
+	}
+
+	return strings.ToLower(name), rest, true
+}
+
+// Args are the parsed arguments of a command.
+type Args map[string]interface{}
+
+// Has reports wheather the argument name was given.
+func (a Args) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+// String returns the argument name of type ArgWord, ArgUser or ArgRest.
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Int returns the argument name of type ArgInt.
+func (a Args) Int(name string) int {
+	i, _ := a[name].(int)
+	return i
+}
+
+// Duration returns the argument name of type ArgDuration.
+func (a Args) Duration(name string) time.Duration {
+	d, _ := a[name].(time.Duration)
+	return d
+}
@@ -0,0 +1,129 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInvocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		message  string
+		wantName string
+		wantRest string
+		wantOk   bool
+	}{
+		{"command with arguments", "~", "~Rate this bot", "rate", "this bot", true},
+		{"command without arguments", "~", "~ping", "ping", "", true},
+		{"longer prefix", "!!", "!!ping", "ping", "", true},
+		{"other prefix", "~", "!ping", "", "", false},
+		{"only prefix", "~", "~ ping", "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			name, rest, ok := ParseInvocation(test.prefix, test.message)
+
+			assert.Equal(test.wantOk, ok)
+			assert.Equal(test.wantName, name)
+			assert.Equal(test.wantRest, rest)
+		})
+	}
+}
+
+func TestCommandParse(t *testing.T) {
+	cmd := &Command{
+		Name: "nuke",
+		Args: []Arg{
+			{Name: "user", Type: ArgUser},
+			{Name: "count", Type: ArgInt},
+			{Name: "duration", Type: ArgDuration, Optional: true},
+			{Name: "reason", Type: ArgRest, Optional: true},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    Args
+		wantErr bool
+	}{
+		{"all arguments", "@SomeOne 3 10m spamming links", Args{
+			"user":     "someone",
+			"count":    3,
+			"duration": 10 * time.Minute,
+			"reason":   "spamming links",
+		}, false},
+		{"optional arguments missing", "someone 3", Args{
+			"user":  "someone",
+			"count": 3,
+		}, false},
+		{"missing argument", "someone", nil, true},
+		{"invalid int", "someone three", nil, true},
+		{"invalid duration", "someone 3 forever", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, err := cmd.Parse(test.text)
+
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, args)
+		})
+	}
+
+	// Commands without arguments ignore the rest of the message
+	args, err := (&Command{Name: "ping"}).Parse("pong")
+	assert.NoError(t, err)
+	assert.Empty(t, args)
+
+	_, err = (&Command{Name: "join", Args: []Arg{{Name: "channel", Type: ArgWord}}}).Parse("a b")
+	assert.EqualError(t, err, "too many arguments")
+}
+
+func TestCommandUsage(t *testing.T) {
+	cmd := &Command{
+		Name: "tell",
+		Args: []Arg{
+			{Name: "user", Type: ArgUser},
+			{Name: "message", Type: ArgRest, Optional: true},
+		},
+	}
+
+	assert.Equal(t, "~tell <user> [message…]", cmd.Usage("~"))
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30", 30 * time.Second, false},
+		{"10m", 10 * time.Minute, false},
+		{"1d", 24 * time.Hour, false},
+		{"1w2d3h", 9*24*time.Hour + 3*time.Hour, false},
+		{"soon", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			got, err := ParseDuration(test.in)
+
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
@@ -2,10 +2,12 @@ package actions
 
 import (
 	"errors"
-	"os"
-	"regexp"
 )
 
+func init() {
+	Register(PriorityHigh, newDebugAction())
+}
+
 type debugAction struct {
 	options *Options
 }
@@ -14,8 +16,13 @@ func newDebugAction() *debugAction {
 	return &debugAction{
 		options: &Options{
 			Name: "debug",
-			Re:   regexp.MustCompile(`(?i)^~debug (\w+)`),
-			Perm: Owner,
+			Cmd: &Command{
+				Name: "debug",
+				Args: []Arg{{Name: "action", Type: ArgWord}},
+			},
+			Perm:        Owner,
+			Description: "Debugging helpers like reconnect and exit.",
+			Examples:    []string{"~debug reconnect"},
 		},
 	}
 }
@@ -25,7 +32,7 @@ func (a debugAction) GetOptions() *Options {
 }
 
 func (a debugAction) Run(e *Event) error {
-	action := e.Match[1]
+	action := e.Args.String("action")
 
 	switch action {
 	case "enable", "disable":
@@ -34,8 +41,11 @@ func (a debugAction) Run(e *Event) error {
 		e.Log.Info().Msg("Reconnecting")
 		e.Twitch.Disconnect()
 	case "exit":
+		if e.Stop == nil {
+			return errors.New("stopping is not supported")
+		}
 		e.Log.Info().Msg("Exiting")
-		os.Exit(0)
+		e.Stop()
 	}
 
 	return nil
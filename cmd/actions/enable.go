@@ -0,0 +1,99 @@
+package actions
+
+import (
+	"sync"
+
+	"github.com/chronophylos/chb3/config"
+	"github.com/chronophylos/chb3/state"
+)
+
+// Actions can be enabled and disabled at runtime. These overrides take
+// precedence over the defaults in the config file which take precedence over
+// Options.Disabled and Options.DisabledChannels.
+var (
+	overridesMu sync.RWMutex
+	overrides   = map[string]state.ActionState{}
+)
+
+// LoadStates replaces all runtime overrides with states.
+func LoadStates(states []state.ActionState) {
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+
+	overrides = map[string]state.ActionState{}
+	for _, s := range states {
+		overrides[s.Name] = s
+	}
+}
+
+// SetEnabled overrides the enable state of the action name in channel. If
+// channel is empty it is overridden in all channels and all overrides for
+// single channels are removed.
+func SetEnabled(name, channel string, enabled bool) {
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+
+	s := overrides[name]
+	s.Name = name
+
+	if channel == "" {
+		s.Enabled = &enabled
+		s.Channels = nil
+	} else {
+		channels := map[string]bool{channel: enabled}
+		for c, e := range s.Channels {
+			if c != channel {
+				channels[c] = e
+			}
+		}
+		s.Channels = channels
+	}
+
+	overrides[name] = s
+}
+
+// GetState returns the runtime override of the action name.
+func GetState(name string) (state.ActionState, bool) {
+	overridesMu.RLock()
+	defer overridesMu.RUnlock()
+
+	s, ok := overrides[name]
+	return s, ok
+}
+
+// IsEnabled reports wheather the action with options opt may run in channel.
+func IsEnabled(opt *Options, channel string) bool {
+	disabled := opt.Disabled
+	disabledInChannel := opt.DisabledChannels[channel]
+
+	if defaults, ok := config.Get().Actions[opt.Name]; ok {
+		if defaults.Disabled != nil {
+			disabled = *defaults.Disabled
+		}
+		if defaults.DisabledChannels != nil {
+			disabledInChannel = false
+			for _, c := range defaults.DisabledChannels {
+				if c == channel {
+					disabledInChannel = true
+				}
+			}
+		}
+	}
+
+	if s, ok := GetState(opt.Name); ok {
+		if enabled, ok := s.Channels[channel]; ok {
+			return enabled
+		}
+		if s.Enabled != nil {
+			if !*s.Enabled {
+				return false
+			}
+			return !disabledInChannel
+		}
+	}
+
+	if disabled {
+		return false
+	}
+	return !disabledInChannel
+}
@@ -4,6 +4,10 @@ import (
 	"regexp"
 )
 
+func init() {
+	Register(PriorityLow, newErDrAction())
+}
+
 type erdrAction struct {
 	options *Options
 }
@@ -11,8 +15,9 @@ type erdrAction struct {
 func newErDrAction() *erdrAction {
 	return &erdrAction{
 		options: &Options{
-			Name: "er dr",
-			Re:   regexp.MustCompile(`er dr`),
+			Name:        "er dr",
+			Re:          regexp.MustCompile(`er dr`),
+			Description: "Completes a very old joke of nightbot.",
 		},
 	}
 }
@@ -1,9 +1,21 @@
 package actions
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/chronophylos/chb3/chatlog"
+	"github.com/chronophylos/chb3/config"
+	"github.com/chronophylos/chb3/history"
+	"github.com/chronophylos/chb3/i18n"
+	"github.com/chronophylos/chb3/markov"
 	"github.com/chronophylos/chb3/nominatim"
 	"github.com/chronophylos/chb3/openweather"
+	"github.com/chronophylos/chb3/poll"
+	"github.com/chronophylos/chb3/raffle"
 	"github.com/chronophylos/chb3/state"
+	"github.com/chronophylos/chb3/stats"
+	"github.com/chronophylos/chb3/twotsch"
 	"github.com/gempir/go-twitch-irc/v2"
 	"github.com/rs/zerolog"
 )
@@ -23,9 +35,20 @@ const (
 	Owner
 )
 
+// ParsePermission returns the permission named s. The name is compared
+// without regard to case.
+func ParsePermission(s string) (Permission, error) {
+	for perm := Everyone; perm <= Owner; perm++ {
+		if strings.EqualFold(perm.String(), s) {
+			return perm, nil
+		}
+	}
+	return Everyone, fmt.Errorf("unknown permission %s", s)
+}
+
 type Event struct {
 	Log           zerolog.Logger
-	Twitch        *twitch.Client
+	Twitch        *twotsch.Client
 	State         *state.Client
 	Weather       *openweather.Client
 	Location      *nominatim.Client
@@ -33,11 +56,44 @@ type Event struct {
 	BotName       string
 	Debug         bool
 
+	// History are the recent messages of every channel.
+	History *history.History
+
+	// Logs stores logged messages. It is nil if logging is disabled.
+	Logs chatlog.Store
+
+	// Stats counts messages. May be nil.
+	Stats *stats.Counter
+
+	// Markov are the markov chains of all channels and users. May be nil.
+	Markov *markov.Model
+
+	// Polls are the running polls of all channels.
+	Polls *poll.Polls
+
+	// Raffles are the running raffles of all channels.
+	Raffles *raffle.Raffles
+
+	// Stop shuts the bot down gracefully. May be nil.
+	Stop func()
+
 	Msg   *twitch.PrivateMessage
+	User  *state.User
 	Match []string
 
+	// Args are the parsed arguments if the action was invoked as a command.
+	Args Args
+	// Prefix is the command prefix of the current channel.
+	Prefix string
+
+	// Actions are all actions the event could have triggered.
+	Actions Actions
+
 	Sleeping bool
 
+	// Language is the language code replies should be written in.
+	Language string
+
 	Perm Permission
 
 	Skipped bool
@@ -65,6 +121,23 @@ func (e *Event) Say(message string) {
 	e.Twitch.Say(e.Msg.Channel, message)
 }
 
+// Command sends the chat command command like `/timeout` to the channel. In
+// contrast to Say it is not checked by the outbound filter.
+func (e *Event) Command(command string) {
+	e.Twitch.Command(e.Msg.Channel, command)
+}
+
+// T translates key into the language of the event. See i18n.T.
+func (e *Event) T(key string, args ...interface{}) string {
+	return i18n.T(e.Language, key, args...)
+}
+
+// Plural translates the pluralized key into the language of the event. See
+// i18n.Plural.
+func (e *Event) Plural(key string, n int, args ...interface{}) string {
+	return i18n.Plural(e.Language, key, n, args...)
+}
+
 // HasPermission compares perm with the permission level of the sender and
 // reports wheather the sender has a permission of at least perm.
 func (e *Event) HasPermission(perm Permission) bool {
@@ -103,7 +176,7 @@ func (e *Event) IsBroadcaster() bool {
 
 // IsOwner reports wheather the sender is the bots owner.
 func (e *Event) IsOwner() bool {
-	return e.Msg.User.ID == "54946241"
+	return config.Get().IsOwner(e.Msg.User.ID)
 }
 
 // IsBot reports wheather the message was sent by a bot.
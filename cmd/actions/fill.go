@@ -7,6 +7,10 @@ import (
 	"time"
 )
 
+func init() {
+	Register(PriorityNormal, newFillAction())
+}
+
 // The code here closely represents supinics code
 // Retrieved on 2020-01-04 01:01
 // Link: https://supinic.com/bot/command/175/code
@@ -20,8 +24,11 @@ func newFillAction() *fillAction {
 
 	return &fillAction{
 		options: &Options{
-			Name: "fill",
-			Re:   regexp.MustCompile(`(?i)^~fill(o?) (.*)`),
+			Name:        "fill",
+			Re:          regexp.MustCompile(`(?i)^~fill(o?) (.*)`),
+			Description: "Fills a message with the given words in random order or in order with fillo.",
+			Usage:       "~fill[o] <words…>",
+			Examples:    []string{"~fill Kappa Keepo", "~fillo PogChamp Pog"},
 		},
 		limit: 400,
 	}
@@ -0,0 +1,165 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chronophylos/chb3/filter"
+	"github.com/chronophylos/chb3/state"
+)
+
+func init() {
+	Register(PriorityNormal, newFilterAction())
+}
+
+type filterAction struct {
+	options *Options
+}
+
+func newFilterAction() *filterAction {
+	return &filterAction{
+		options: &Options{
+			Name: "filter",
+			Cmd: &Command{
+				Name: "filter",
+				Args: []Arg{
+					{Name: "subcommand", Type: ArgWord},
+					{Name: "rule", Type: ArgRest, Optional: true},
+				},
+			},
+			Perm:      Broadcaster,
+			Sleepless: true,
+			Usage:     "~filter add <ignore|delete|timeout|ban> [duration] <word or /regex/> | ~filter remove <word or /regex/> | ~filter list",
+			Description: "Manages the word filter of this channel. Matching messages are ignored, deleted or their sender is timed out or banned. " +
+				"Deleting, timeouts and bans only work if the bot is a moderator.",
+			Examples: []string{"~filter add delete heck", "~filter add timeout 1h /fr[ie]+ck/", "~filter remove heck", "~filter list"},
+		},
+	}
+}
+
+func (a filterAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a filterAction) Run(e *Event) error {
+	rest := e.Args.String("rule")
+
+	switch e.Args.String("subcommand") {
+	case "add":
+		return a.add(e, rest)
+	case "remove", "rm", "delete":
+		return a.remove(e, rest)
+	case "list", "ls":
+		return a.list(e)
+	}
+
+	e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+	return nil
+}
+
+func (a filterAction) add(e *Event, rest string) error {
+	fields := strings.SplitN(rest, " ", 2)
+	if len(fields) < 2 {
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	rule := state.FilterRule{
+		Channel: e.Msg.Channel,
+		Action:  strings.ToLower(fields[0]),
+		By:      e.Msg.User.Name,
+		Created: e.Msg.Time,
+	}
+	rest = fields[1]
+
+	if rule.Action == filter.Timeout.String() {
+		rule.Duration = filter.DefaultTimeout
+
+		fields = strings.SplitN(rest, " ", 2)
+		if d, err := ParseDuration(fields[0]); err == nil && len(fields) == 2 {
+			rule.Duration = d
+			rest = fields[1]
+		}
+	}
+
+	rule.Pattern, rule.Regex = parsePattern(rest)
+
+	if _, err := NewFilterRule(rule); err != nil {
+		e.Say(e.T("filter.invalid", err))
+		return nil
+	}
+
+	e.Log.Info().
+		Str("pattern", rule.Pattern).
+		Bool("regex", rule.Regex).
+		Str("rule-action", rule.Action).
+		Msg("Adding filter rule")
+
+	if err := e.State.AddFilterRule(rule); err != nil {
+		return fmt.Errorf("adding filter rule: %v", err)
+	}
+	AddFilterRule(rule)
+
+	e.Say(e.T("filter.added", formatPattern(rule), rule.Action))
+
+	return nil
+}
+
+func (a filterAction) remove(e *Event, rest string) error {
+	if rest == "" {
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	rule := state.FilterRule{}
+	rule.Pattern, rule.Regex = parsePattern(rest)
+
+	ok, err := e.State.RemoveFilterRule(e.Msg.Channel, rule.Pattern, rule.Regex)
+	if err != nil {
+		return fmt.Errorf("removing filter rule: %v", err)
+	}
+	RemoveFilterRule(e.Msg.Channel, rule.Pattern, rule.Regex)
+
+	if !ok {
+		e.Say(e.T("filter.missing", formatPattern(rule)))
+		return nil
+	}
+
+	e.Say(e.T("filter.removed", formatPattern(rule)))
+
+	return nil
+}
+
+func (a filterAction) list(e *Event) error {
+	list := GetFilterRules(e.Msg.Channel)
+	if len(list) == 0 {
+		e.Say(e.T("filter.empty"))
+		return nil
+	}
+
+	strs := make([]string, len(list))
+	for i, rule := range list {
+		strs[i] = fmt.Sprintf("%s (%s)", formatPattern(rule), rule.Action)
+	}
+
+	e.Say(e.T("filter.list", strings.Join(strs, ", ")))
+
+	return nil
+}
+
+// parsePattern reads /pattern/ as a regular expression and everything else as
+// a word.
+func parsePattern(s string) (pattern string, regex bool) {
+	s = strings.TrimSpace(s)
+	if len(s) > 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		return s[1 : len(s)-1], true
+	}
+	return s, false
+}
+
+func formatPattern(rule state.FilterRule) string {
+	if rule.Regex {
+		return "/" + rule.Pattern + "/"
+	}
+	return rule.Pattern
+}
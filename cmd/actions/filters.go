@@ -0,0 +1,140 @@
+package actions
+
+import (
+	"sync"
+
+	"github.com/chronophylos/chb3/config"
+	"github.com/chronophylos/chb3/filter"
+	"github.com/chronophylos/chb3/state"
+	"github.com/rs/zerolog/log"
+)
+
+// Filter rules are cached and compiled per channel so messages can be checked
+// without querying the database.
+var (
+	filtersMu sync.RWMutex
+	rules     = map[string][]state.FilterRule{}
+	filters   = map[string]*filter.Filter{}
+)
+
+// LoadFilterRules replaces all cached filter rules with list. Invalid rules
+// are logged and skipped.
+func LoadFilterRules(list []state.FilterRule) {
+	filtersMu.Lock()
+	defer filtersMu.Unlock()
+
+	rules = map[string][]state.FilterRule{}
+	for _, rule := range list {
+		if _, err := NewFilterRule(rule); err != nil {
+			log.Error().
+				Err(err).
+				Str("channel", rule.Channel).
+				Str("pattern", rule.Pattern).
+				Msg("Skipping invalid filter rule")
+			continue
+		}
+		rules[rule.Channel] = append(rules[rule.Channel], rule)
+	}
+
+	filters = map[string]*filter.Filter{}
+	for channel := range rules {
+		compileFilter(channel)
+	}
+}
+
+// AddFilterRule adds rule to the cache. It must be valid.
+func AddFilterRule(rule state.FilterRule) {
+	filtersMu.Lock()
+	defer filtersMu.Unlock()
+
+	list := []state.FilterRule{}
+	for _, r := range rules[rule.Channel] {
+		if r.Pattern != rule.Pattern || r.Regex != rule.Regex {
+			list = append(list, r)
+		}
+	}
+	rules[rule.Channel] = append(list, rule)
+
+	compileFilter(rule.Channel)
+}
+
+// RemoveFilterRule removes the rule with pattern in channel from the cache.
+func RemoveFilterRule(channel, pattern string, regex bool) {
+	filtersMu.Lock()
+	defer filtersMu.Unlock()
+
+	list := []state.FilterRule{}
+	for _, r := range rules[channel] {
+		if r.Pattern != pattern || r.Regex != regex {
+			list = append(list, r)
+		}
+	}
+	rules[channel] = list
+
+	compileFilter(channel)
+}
+
+// GetFilterRules returns the cached filter rules of channel.
+func GetFilterRules(channel string) []state.FilterRule {
+	filtersMu.RLock()
+	defer filtersMu.RUnlock()
+
+	return append([]state.FilterRule{}, rules[channel]...)
+}
+
+// MatchFilter returns the most severe filter rule of channel matching
+// message.
+func MatchFilter(channel, message string) (filter.Rule, bool) {
+	filtersMu.RLock()
+	f := filters[channel]
+	filtersMu.RUnlock()
+
+	return f.Match(message)
+}
+
+// IsFiltered reports wheather message matches a filter rule of channel or a
+// swear of the config.
+func IsFiltered(channel, message string) bool {
+	if _, ok := MatchFilter(channel, message); ok {
+		return true
+	}
+	_, ok := config.Get().MatchSwears(message)
+	return ok
+}
+
+// NewFilterRule converts a stored rule to a filter rule. It fails if the rule
+// is invalid.
+func NewFilterRule(rule state.FilterRule) (filter.Rule, error) {
+	action, err := filter.ParseAction(rule.Action)
+	if err != nil {
+		return filter.Rule{}, err
+	}
+
+	r := filter.Rule{
+		Pattern:  rule.Pattern,
+		Regex:    rule.Regex,
+		Action:   action,
+		Duration: rule.Duration,
+	}
+
+	// Compile the rule on its own to find errors
+	if _, err := filter.New([]filter.Rule{r}); err != nil {
+		return filter.Rule{}, err
+	}
+
+	return r, nil
+}
+
+// compileFilter compiles the filter of channel. filtersMu must be locked.
+func compileFilter(channel string) {
+	list := []filter.Rule{}
+	for _, rule := range rules[channel] {
+		if r, err := NewFilterRule(rule); err == nil {
+			list = append(list, r)
+		}
+	}
+
+	// All rules were checked before so this can't fail
+	f, _ := filter.New(list)
+	filters[channel] = f
+}
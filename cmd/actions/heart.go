@@ -1,8 +1,8 @@
 package actions
 
-import (
-	"regexp"
-)
+func init() {
+	Register(PriorityNormal, newHeartAction())
+}
 
 type heartAction struct {
 	options *Options
@@ -11,8 +11,10 @@ type heartAction struct {
 func newHeartAction() *heartAction {
 	return &heartAction{
 		options: &Options{
-			Name: "heart",
-			Re:   regexp.MustCompile(`^~\<3`),
+			Name:        "heart",
+			Cmd:         &Command{Name: "<3"},
+			Description: "Sends a heart made of math.",
+			Examples:    []string{"~<3"},
 		},
 	}
 }
@@ -4,6 +4,10 @@ import (
 	"regexp"
 )
 
+func init() {
+	Register(PriorityLow, newHelloAction())
+}
+
 type helloAction struct {
 	options *Options
 }
@@ -11,8 +15,11 @@ type helloAction struct {
 func newHelloAction() *helloAction {
 	return &helloAction{
 		options: &Options{
-			Name: "hello",
-			Re:   regexp.MustCompile(`(?i)(hey|hi|h[ea]llo) @?chrono(phylos(bot)?)?`),
+			Name:        "hello",
+			Re:          regexp.MustCompile(`(?i)(hey|hi|h[ea]llo) @?chrono(phylos(bot)?)?`),
+			Description: "Greets you back.",
+			Usage:       "hello chronophylosbot",
+			Examples:    []string{"hey chrono"},
 		},
 	}
 }
@@ -26,7 +33,7 @@ func (a helloAction) Run(e *Event) error {
 
 	// There is an emoji but my stupid Terminal is refusing to show it peepoMad
 	//                                          ↓
-	e.Say("Hello " + e.Msg.User.DisplayName + " 👋")
+	e.Say(e.T("hello.reply", e.Msg.User.DisplayName))
 
 	return nil
 }
@@ -4,6 +4,10 @@ import (
 	"regexp"
 )
 
+func init() {
+	Register(PriorityLow, newHelloStirnbotAction())
+}
+
 type helloStirnbotAction struct {
 	options *Options
 }
@@ -11,8 +15,9 @@ type helloStirnbotAction struct {
 func newHelloStirnbotAction() *helloStirnbotAction {
 	return &helloStirnbotAction{
 		options: &Options{
-			Name: "hello stirnbot",
-			Re:   regexp.MustCompile(`^I'm here FeelsGoodMan$`),
+			Name:        "hello stirnbot",
+			Re:          regexp.MustCompile(`^I'm here FeelsGoodMan$`),
+			Description: "Greets StirnBot when it joins.",
 		},
 	}
 }
@@ -0,0 +1,164 @@
+package actions
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+func init() {
+	Register(PriorityNormal, newHelpAction())
+	Register(PriorityNormal, newCommandsAction())
+}
+
+type helpAction struct {
+	options *Options
+}
+
+func newHelpAction() *helpAction {
+	return &helpAction{
+		options: &Options{
+			Name: "help",
+			Cmd: &Command{
+				Name:    "help",
+				Aliases: []string{"hilfe"},
+				Args:    []Arg{{Name: "command", Type: ArgWord, Optional: true}},
+			},
+			Description: "Explains a command or how to get help.",
+			Examples:    []string{"~help", "~help weather"},
+		},
+	}
+}
+
+func (a helpAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a helpAction) Run(e *Event) error {
+	name := e.Args.String("command")
+	if name == "" {
+		e.Say(e.T("help.general", e.Prefix))
+		return nil
+	}
+
+	name = strings.ToLower(strings.TrimPrefix(name, e.Prefix))
+
+	for _, action := range e.available() {
+		opt := action.GetOptions()
+
+		if commandName(opt) != name && opt.Name != name &&
+			(opt.Cmd == nil || !opt.Cmd.Matches(name)) {
+			continue
+		}
+
+		message := UsageFor(opt, e.Prefix)
+		if message == "" {
+			message = opt.Name
+		}
+		if opt.Description != "" {
+			message += " — " + opt.Description
+		}
+		if len(opt.Examples) > 0 {
+			message += " " + e.T("help.example", e.withPrefix(opt, opt.Examples[0]))
+		}
+
+		e.Say(message)
+		return nil
+	}
+
+	e.Say(e.T("help.unknown", name))
+
+	return nil
+}
+
+type commandsAction struct {
+	options *Options
+}
+
+func newCommandsAction() *commandsAction {
+	return &commandsAction{
+		options: &Options{
+			Name: "commands",
+			Cmd: &Command{
+				Name:    "commands",
+				Aliases: []string{"befehle"},
+			},
+			Description: "Lists all commands you can use in this channel.",
+		},
+	}
+}
+
+func (a commandsAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a commandsAction) Run(e *Event) error {
+	seen := map[string]bool{}
+	names := []string{}
+
+	for _, action := range e.available() {
+		opt := action.GetOptions()
+
+		name := commandName(opt)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if opt.Cmd != nil {
+			names = append(names, e.Prefix+name)
+		} else {
+			names = append(names, DefaultPrefix+name)
+		}
+	}
+
+	sort.Strings(names)
+
+	e.Say(e.T("help.commands", strings.Join(names, " ")))
+
+	return nil
+}
+
+var usageCommandRe = regexp.MustCompile(`^` + regexp.QuoteMeta(DefaultPrefix) + `(\w+)`)
+
+// commandName returns the name of the command of an action. For actions
+// triggered by a regular expression the name is taken from their usage if it
+// looks like a command.
+func commandName(opt *Options) string {
+	if opt.Cmd != nil {
+		return opt.Cmd.Name
+	}
+
+	if match := usageCommandRe.FindStringSubmatch(opt.Usage); match != nil {
+		return match[1]
+	}
+
+	return ""
+}
+
+// available returns all actions the sender is allowed to use in the current
+// channel.
+func (e *Event) available() Actions {
+	available := Actions{}
+
+	for _, action := range e.Actions {
+		opt := action.GetOptions()
+
+		if !e.HasPermission(opt.Perm) || !IsEnabled(opt, e.Msg.Channel) {
+			continue
+		}
+
+		available = append(available, action)
+	}
+
+	return available
+}
+
+// withPrefix replaces the default prefix in example with the prefix of the
+// current channel if the action is a command.
+func (e *Event) withPrefix(opt *Options, example string) string {
+	if opt.Cmd == nil || !strings.HasPrefix(example, DefaultPrefix) {
+		return example
+	}
+	return e.Prefix + strings.TrimPrefix(example, DefaultPrefix)
+}
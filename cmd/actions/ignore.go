@@ -0,0 +1,187 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chronophylos/chb3/config"
+	"github.com/chronophylos/chb3/state"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	Register(PriorityHigh, newIgnoreAction())
+	Register(PriorityHigh, newUnignoreAction())
+}
+
+type ignoreAction struct {
+	options *Options
+}
+
+func newIgnoreAction() *ignoreAction {
+	return &ignoreAction{
+		options: &Options{
+			Name: "ignore",
+			Cmd: &Command{
+				Name: "ignore",
+				Args: []Arg{
+					{Name: "user", Type: ArgUser},
+					{Name: "reason", Type: ArgRest, Optional: true},
+				},
+			},
+			Perm:      Broadcaster,
+			Sleepless: true,
+			Description: "Makes the bot ignore a user. Owners ignore users everywhere, broadcasters only in their channel. " +
+				"The reason may start with a duration like 30m or 1d after which the ignore expires.",
+			Examples: []string{"~ignore someone", "~ignore someone 1d spamming commands"},
+		},
+	}
+}
+
+func (a ignoreAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a ignoreAction) Run(e *Event) error {
+	name := e.Args.String("user")
+	channel := ignoreChannel(e)
+
+	user, err := e.State.GetUserByName(name)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			e.Say(e.T("ignore.unknown", name))
+			return nil
+		}
+		return fmt.Errorf("getting user: %v", err)
+	}
+
+	if name == e.BotName || name == e.Msg.Channel || config.Get().IsOwner(user.ID) {
+		e.Say(e.T("ignore.protected", name))
+		return nil
+	}
+
+	ignore := state.Ignore{
+		UserID:   user.ID,
+		UserName: user.Name,
+		Channel:  channel,
+		By:       e.Msg.User.Name,
+		Created:  e.Msg.Time,
+	}
+
+	d, reason := parseIgnoreReason(e.Args.String("reason"))
+	if d > 0 {
+		ignore.Expires = e.Msg.Time.Add(d)
+	}
+	ignore.Reason = reason
+
+	e.Log.Info().
+		Str("user", name).
+		Str("scope", channel).
+		Time("expires", ignore.Expires).
+		Str("reason", ignore.Reason).
+		Msg("Ignoring user")
+
+	if err := e.State.AddIgnore(ignore); err != nil {
+		return fmt.Errorf("adding ignore: %v", err)
+	}
+	AddIgnore(ignore)
+
+	reply := e.T("ignore.global", name)
+	if channel != "" {
+		reply = e.T("ignore.channel", name)
+	}
+	if !ignore.Expires.IsZero() {
+		reply += " " + e.T("ignore.expires", formatDuration(e, ignore.Expires.Sub(e.Msg.Time)))
+	}
+	e.Say(reply)
+
+	return nil
+}
+
+// parseIgnoreReason reads `[duration] [reason]`. The duration needs a unit so
+// reasons starting with a number are kept.
+func parseIgnoreReason(s string) (time.Duration, string) {
+	fields := strings.SplitN(strings.TrimSpace(s), " ", 2)
+	if !hasDurationUnit(fields[0]) {
+		return 0, strings.TrimSpace(s)
+	}
+
+	d, err := ParseDuration(fields[0])
+	if err != nil || d <= 0 {
+		return 0, strings.TrimSpace(s)
+	}
+
+	var reason string
+	if len(fields) == 2 {
+		reason = strings.TrimSpace(fields[1])
+	}
+	return d, reason
+}
+
+type unignoreAction struct {
+	options *Options
+}
+
+func newUnignoreAction() *unignoreAction {
+	return &unignoreAction{
+		options: &Options{
+			Name: "unignore",
+			Cmd: &Command{
+				Name: "unignore",
+				Args: []Arg{{Name: "user", Type: ArgUser}},
+			},
+			Perm:        Broadcaster,
+			Sleepless:   true,
+			Description: "Stops ignoring a user. Owners remove global ignores, broadcasters the ignores of their channel.",
+			Examples:    []string{"~unignore someone"},
+		},
+	}
+}
+
+func (a unignoreAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a unignoreAction) Run(e *Event) error {
+	name := e.Args.String("user")
+	channel := ignoreChannel(e)
+
+	user, err := e.State.GetUserByName(name)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			e.Say(e.T("ignore.unknown", name))
+			return nil
+		}
+		return fmt.Errorf("getting user: %v", err)
+	}
+
+	ok, err := e.State.RemoveIgnore(user.ID, channel)
+	if err != nil {
+		return fmt.Errorf("removing ignore: %v", err)
+	}
+	RemoveIgnore(user.ID, channel)
+
+	if !ok {
+		e.Say(e.T("unignore.missing", name))
+		return nil
+	}
+
+	e.Log.Info().
+		Str("user", name).
+		Str("scope", channel).
+		Msg("Unignored user")
+
+	e.Say(e.T("unignore.done", name))
+
+	return nil
+}
+
+// ignoreChannel returns the channel ignores of the sender apply to. Owners
+// manage global ignores.
+func ignoreChannel(e *Event) string {
+	if e.Perm == Owner {
+		return ""
+	}
+	return e.Msg.Channel
+}
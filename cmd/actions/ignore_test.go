@@ -0,0 +1,29 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIgnoreReason(t *testing.T) {
+	var tests = []struct {
+		in     string
+		d      time.Duration
+		reason string
+	}{
+		{"", 0, ""},
+		{"spamming commands", 0, "spamming commands"},
+		{"1d spamming commands", 24 * time.Hour, "spamming commands"},
+		{"30m", 30 * time.Minute, ""},
+		{"1 spammer", 0, "1 spammer"},
+		{"2nd warning", 0, "2nd warning"},
+	}
+
+	for _, test := range tests {
+		d, reason := parseIgnoreReason(test.in)
+		assert.Equal(t, test.d, d, test.in)
+		assert.Equal(t, test.reason, reason, test.in)
+	}
+}
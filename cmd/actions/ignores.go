@@ -0,0 +1,63 @@
+package actions
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+)
+
+// Ignored users are cached so messages can be checked without querying the
+// database. The cache is keyed by user ID and channel; global ignores use an
+// empty channel.
+var (
+	ignoresMu sync.RWMutex
+	ignores   = map[ignoreKey]state.Ignore{}
+)
+
+type ignoreKey struct {
+	userID  string
+	channel string
+}
+
+// LoadIgnores replaces all cached ignores with list.
+func LoadIgnores(list []state.Ignore) {
+	ignoresMu.Lock()
+	defer ignoresMu.Unlock()
+
+	ignores = map[ignoreKey]state.Ignore{}
+	for _, i := range list {
+		ignores[ignoreKey{i.UserID, i.Channel}] = i
+	}
+}
+
+// AddIgnore adds i to the cache.
+func AddIgnore(i state.Ignore) {
+	ignoresMu.Lock()
+	defer ignoresMu.Unlock()
+
+	ignores[ignoreKey{i.UserID, i.Channel}] = i
+}
+
+// RemoveIgnore removes the ignore of userID in channel from the cache.
+func RemoveIgnore(userID, channel string) {
+	ignoresMu.Lock()
+	defer ignoresMu.Unlock()
+
+	delete(ignores, ignoreKey{userID, channel})
+}
+
+// IsIgnored reports wheather the user with the twitch ID userID is ignored
+// in channel, either globally or only there.
+func IsIgnored(userID, channel string) bool {
+	ignoresMu.RLock()
+	defer ignoresMu.RUnlock()
+
+	now := time.Now()
+	for _, key := range []ignoreKey{{userID, ""}, {userID, channel}} {
+		if i, ok := ignores[key]; ok && !i.Expired(now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsIgnored(t *testing.T) {
+	LoadIgnores([]state.Ignore{
+		{UserID: "1"},
+		{UserID: "2", Channel: "chronophylos"},
+		{UserID: "3", Expires: time.Now().Add(-time.Minute)},
+	})
+	defer LoadIgnores(nil)
+
+	assert.True(t, IsIgnored("1", "chronophylos"))
+	assert.True(t, IsIgnored("2", "chronophylos"))
+	assert.False(t, IsIgnored("2", "someone"))
+	assert.False(t, IsIgnored("3", "chronophylos"), "expired")
+
+	RemoveIgnore("1", "")
+	assert.False(t, IsIgnored("1", "chronophylos"))
+}
@@ -0,0 +1,112 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chronophylos/chb3/i18n"
+)
+
+func init() {
+	Register(PriorityNormal, newChannelLanguageAction())
+	Register(PriorityNormal, newUserLanguageAction())
+}
+
+type channelLanguageAction struct {
+	options *Options
+}
+
+func newChannelLanguageAction() *channelLanguageAction {
+	return &channelLanguageAction{
+		options: &Options{
+			Name: "language.channel",
+			Cmd: &Command{
+				Name:    "language",
+				Aliases: []string{"sprache"},
+				Args:    []Arg{{Name: "language", Type: ArgWord}},
+			},
+			Perm:        Broadcaster,
+			Description: "Sets the language the bot speaks in this channel.",
+			Examples:    []string{"~language de"},
+		},
+	}
+}
+
+func (a channelLanguageAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a channelLanguageAction) Run(e *Event) error {
+	language := strings.ToLower(e.Args.String("language"))
+
+	if !i18n.Has(language) {
+		e.Say(e.T("language.unknown", language, strings.Join(i18n.Languages(), ", ")))
+		return nil
+	}
+
+	e.Log.Info().
+		Str("language", language).
+		Msg("Setting channel language")
+
+	if err := e.State.SetChannelLanguage(e.Msg.Channel, language); err != nil {
+		return fmt.Errorf("setting channel language: %v", err)
+	}
+
+	e.Say(i18n.T(language, "language.channel"))
+
+	return nil
+}
+
+type userLanguageAction struct {
+	options *Options
+}
+
+func newUserLanguageAction() *userLanguageAction {
+	return &userLanguageAction{
+		options: &Options{
+			Name: "language.user",
+			Cmd: &Command{
+				Name:    "mylanguage",
+				Aliases: []string{"meinesprache"},
+				Args:    []Arg{{Name: "language", Type: ArgWord}},
+			},
+			Description: "Sets the language the bot speaks with you. Use reset to speak the channels language again.",
+			Examples:    []string{"~mylanguage en", "~mylanguage reset"},
+		},
+	}
+}
+
+func (a userLanguageAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a userLanguageAction) Run(e *Event) error {
+	language := strings.ToLower(e.Args.String("language"))
+
+	switch language {
+	case "reset", "default":
+		language = ""
+	default:
+		if !i18n.Has(language) {
+			e.Say(e.T("language.unknown", language, strings.Join(i18n.Languages(), ", ")))
+			return nil
+		}
+	}
+
+	e.Log.Info().
+		Str("language", language).
+		Msg("Setting user language")
+
+	if err := e.State.SetUserLanguage(e.Msg.User.ID, language); err != nil {
+		return fmt.Errorf("setting user language: %v", err)
+	}
+
+	if language == "" {
+		e.Say(e.T("language.user_reset"))
+		return nil
+	}
+
+	e.Say(i18n.T(language, "language.user"))
+
+	return nil
+}
@@ -5,6 +5,10 @@ import (
 	"strings"
 )
 
+func init() {
+	Register(PriorityLow, newLocationAction())
+}
+
 type locationAction struct {
 	options *Options
 }
@@ -12,8 +16,11 @@ type locationAction struct {
 func newLocationAction() *locationAction {
 	return &locationAction{
 		options: &Options{
-			Name: "location",
-			Re:   regexp.MustCompile(`(?i)^wo (ist|liegt) (.*)\?+`),
+			Name:        "location",
+			Re:          regexp.MustCompile(`(?i)^wo (ist|liegt) (.*)\?+`),
+			Description: "Links a place on OpenStreetMap.",
+			Usage:       "wo ist <place>?",
+			Examples:    []string{"wo liegt Berlin?"},
 		},
 	}
 }
@@ -26,13 +33,13 @@ func (a locationAction) Run(e *Event) error {
 	where := e.Match[2]
 
 	if strings.ToLower(where) == "bielefeld" {
-		e.Say("Ich kann Bielefeld nicht finden")
+		e.Say(e.T("location.not_found", "Bielefeld"))
 		return nil
 	}
 
 	place, err := e.Location.GetPlace(where)
 	if err != nil {
-		e.Say("Ich kann " + where + " nicht finden")
+		e.Say(e.T("location.not_found", where))
 		return nil
 	}
 
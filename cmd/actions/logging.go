@@ -0,0 +1,166 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chronophylos/chb3/markov"
+)
+
+func init() {
+	Register(PriorityNormal, newLoggingAction())
+	Register(PriorityNormal, newOptOutAction())
+	Register(PriorityNormal, newOptInAction())
+}
+
+type loggingAction struct {
+	options *Options
+}
+
+func newLoggingAction() *loggingAction {
+	return &loggingAction{
+		options: &Options{
+			Name: "logging",
+			Cmd: &Command{
+				Name: "logging",
+				Args: []Arg{{Name: "value", Type: ArgWord, Optional: true}},
+			},
+			Perm:        Broadcaster,
+			Sleepless:   true,
+			Usage:       "~logging [on|off]",
+			Description: "Turns logging of messages in this channel on or off or shows wheather it is on.",
+			Examples:    []string{"~logging", "~logging on"},
+		},
+	}
+}
+
+func (a loggingAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a loggingAction) Run(e *Event) error {
+	if e.Logs == nil {
+		e.Say(e.T("logs.disabled"))
+		return nil
+	}
+
+	var on bool
+	switch value := strings.ToLower(e.Args.String("value")); value {
+	case "":
+		channel, err := e.State.GetChannel(e.Msg.Channel)
+		if err != nil {
+			return fmt.Errorf("getting channel: %v", err)
+		}
+		if channel.Logging {
+			e.Say(e.T("logs.on"))
+		} else {
+			e.Say(e.T("logs.off"))
+		}
+		return nil
+	case "on", "off":
+		on = value == "on"
+	default:
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	e.Log.Info().
+		Bool("logging", on).
+		Msg("Setting logging")
+
+	if err := e.State.SetChannelLogging(e.Msg.Channel, on); err != nil {
+		return fmt.Errorf("setting logging: %v", err)
+	}
+	SetLogging(e.Msg.Channel, on)
+
+	if on {
+		e.Say(e.T("logs.on"))
+	} else {
+		e.Say(e.T("logs.off"))
+	}
+
+	return nil
+}
+
+type optOutAction struct {
+	options *Options
+}
+
+func newOptOutAction() *optOutAction {
+	return &optOutAction{
+		options: &Options{
+			Name:      "optout",
+			Cmd:       &Command{Name: "optout"},
+			Sleepless: true,
+			Description: "Stops logging your messages and removes all your logged messages and what ~markov learned from them. " +
+				"The chains of channels are learned again from the remaining logged messages shortly after.",
+			Examples: []string{"~optout"},
+		},
+	}
+}
+
+func (a optOutAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a optOutAction) Run(e *Event) error {
+	e.Log.Info().Msg("Opting out of logging")
+
+	if err := e.State.SetUserLogOptOut(e.Msg.User.ID, true); err != nil {
+		return fmt.Errorf("opting out: %v", err)
+	}
+	SetLogOptOut(e.Msg.User.ID, true)
+
+	if e.Logs != nil {
+		if err := e.Logs.RemoveLogLines(e.Msg.User.ID); err != nil {
+			return fmt.Errorf("removing log lines: %v", err)
+		}
+	}
+	if e.Markov != nil {
+		if err := e.Markov.Forget(markov.UserKey(e.Msg.User.ID)); err != nil {
+			return fmt.Errorf("removing markov chain: %v", err)
+		}
+		// The channel chains are learned again in the background once the
+		// lines of the user are gone
+		for _, channel := range e.Markov.Channels() {
+			e.Markov.Stale(channel)
+		}
+	}
+
+	e.Say(e.T("logs.optout", e.Msg.User.DisplayName))
+
+	return nil
+}
+
+type optInAction struct {
+	options *Options
+}
+
+func newOptInAction() *optInAction {
+	return &optInAction{
+		options: &Options{
+			Name:        "optin",
+			Cmd:         &Command{Name: "optin"},
+			Sleepless:   true,
+			Description: "Logs your messages again after opting out.",
+			Examples:    []string{"~optin"},
+		},
+	}
+}
+
+func (a optInAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a optInAction) Run(e *Event) error {
+	e.Log.Info().Msg("Opting in to logging")
+
+	if err := e.State.SetUserLogOptOut(e.Msg.User.ID, false); err != nil {
+		return fmt.Errorf("opting in: %v", err)
+	}
+	SetLogOptOut(e.Msg.User.ID, false)
+
+	e.Say(e.T("logs.optin", e.Msg.User.DisplayName))
+
+	return nil
+}
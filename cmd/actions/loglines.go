@@ -0,0 +1,175 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/chronophylos/chb3/state"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	Register(PriorityNormal, newLastLineAction())
+	Register(PriorityNormal, newRandomLineAction())
+	Register(PriorityNormal, newSearchAction())
+}
+
+type lastLineAction struct {
+	options *Options
+}
+
+func newLastLineAction() *lastLineAction {
+	return &lastLineAction{
+		options: &Options{
+			Name: "lastline",
+			Cmd: &Command{
+				Name: "lastline",
+				Args: []Arg{{Name: "user", Type: ArgUser}},
+			},
+			Description: "Shows the last logged message of a user in this channel.",
+			Examples:    []string{"~lastline someone"},
+		},
+	}
+}
+
+func (a lastLineAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a lastLineAction) Run(e *Event) error {
+	user, ok, err := logUser(e)
+	if !ok {
+		return err
+	}
+
+	line, err := e.Logs.GetLastLogLine(e.Msg.Channel, user.ID)
+	if err != nil {
+		return fmt.Errorf("getting last line: %v", err)
+	}
+	if line == nil {
+		e.Say(e.T("logs.none", user.Name))
+		return nil
+	}
+
+	e.Say(e.T("logs.lastline", line.UserName, formatDuration(e, e.Msg.Time.Sub(line.Time)), line.Text))
+
+	return nil
+}
+
+type randomLineAction struct {
+	options *Options
+}
+
+func newRandomLineAction() *randomLineAction {
+	return &randomLineAction{
+		options: &Options{
+			Name: "rl",
+			Cmd: &Command{
+				Name:    "rl",
+				Aliases: []string{"randomline"},
+				Args:    []Arg{{Name: "user", Type: ArgUser}},
+			},
+			Description: "Shows a random logged message of a user in this channel.",
+			Examples:    []string{"~rl someone"},
+		},
+	}
+}
+
+func (a randomLineAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a randomLineAction) Run(e *Event) error {
+	user, ok, err := logUser(e)
+	if !ok {
+		return err
+	}
+
+	line, err := e.Logs.GetRandomLogLine(e.Msg.Channel, user.ID)
+	if err != nil {
+		return fmt.Errorf("getting random line: %v", err)
+	}
+	if line == nil {
+		e.Say(e.T("logs.none", user.Name))
+		return nil
+	}
+
+	e.Say(formatLogLine(e, *line))
+
+	return nil
+}
+
+type searchAction struct {
+	options *Options
+}
+
+func newSearchAction() *searchAction {
+	return &searchAction{
+		options: &Options{
+			Name: "search",
+			Cmd: &Command{
+				Name: "search",
+				Args: []Arg{
+					{Name: "user", Type: ArgUser},
+					{Name: "text", Type: ArgRest},
+				},
+			},
+			Description: "Shows the newest logged message of a user in this channel containing the text.",
+			Examples:    []string{"~search someone pizza"},
+		},
+	}
+}
+
+func (a searchAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a searchAction) Run(e *Event) error {
+	user, ok, err := logUser(e)
+	if !ok {
+		return err
+	}
+
+	lines, err := e.Logs.SearchLogLines(e.Msg.Channel, user.ID, e.Args.String("text"), 1)
+	if err != nil {
+		return fmt.Errorf("searching lines: %v", err)
+	}
+	if len(lines) == 0 {
+		e.Say(e.T("logs.notfound", user.Name))
+		return nil
+	}
+
+	e.Say(formatLogLine(e, lines[0]))
+
+	return nil
+}
+
+// logUser returns the user named by the user argument. If ok is false a reply
+// was sent or err must be returned.
+func logUser(e *Event) (user state.User, ok bool, err error) {
+	if e.Logs == nil {
+		e.Say(e.T("logs.disabled"))
+		return user, false, nil
+	}
+
+	name := e.Args.String("user")
+
+	user, err = e.State.GetUserByName(name)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			e.Say(e.T("logs.none", name))
+			return user, false, nil
+		}
+		return user, false, fmt.Errorf("getting user: %v", err)
+	}
+
+	if IsLogOptOut(user.ID) {
+		e.Say(e.T("logs.optedout", user.Name))
+		return user, false, nil
+	}
+
+	return user, true, nil
+}
+
+func formatLogLine(e *Event, line state.LogLine) string {
+	return e.T("logs.line", line.Time.UTC().Format("2006-01-02 15:04"), line.UserName, line.Text)
+}
@@ -0,0 +1,80 @@
+package actions
+
+import (
+	"sync"
+)
+
+// The channels with logging turned on and the users who opted out of logging
+// are cached so messages can be logged without querying the database.
+var (
+	logsMu         sync.RWMutex
+	loggedChannels = map[string]bool{}
+	logOptOuts     = map[string]bool{}
+)
+
+// LoadLogging replaces the cached logged channels and opted out user IDs.
+func LoadLogging(channels, optOuts []string) {
+	logsMu.Lock()
+	defer logsMu.Unlock()
+
+	loggedChannels = map[string]bool{}
+	for _, channel := range channels {
+		loggedChannels[channel] = true
+	}
+
+	logOptOuts = map[string]bool{}
+	for _, id := range optOuts {
+		logOptOuts[id] = true
+	}
+}
+
+// SetLogging sets wheather channel is logged.
+func SetLogging(channel string, on bool) {
+	logsMu.Lock()
+	defer logsMu.Unlock()
+
+	if on {
+		loggedChannels[channel] = true
+	} else {
+		delete(loggedChannels, channel)
+	}
+}
+
+// SetLogOptOut sets wheather the user with the twitch ID userID opted out of
+// logging.
+func SetLogOptOut(userID string, optOut bool) {
+	logsMu.Lock()
+	defer logsMu.Unlock()
+
+	if optOut {
+		logOptOuts[userID] = true
+	} else {
+		delete(logOptOuts, userID)
+	}
+}
+
+// IsLogged reports wheather messages of the user with the twitch ID userID in
+// channel are logged.
+func IsLogged(channel, userID string) bool {
+	logsMu.RLock()
+	defer logsMu.RUnlock()
+
+	return loggedChannels[channel] && !logOptOuts[userID]
+}
+
+// IsChannelLogged reports wheather channel is logged.
+func IsChannelLogged(channel string) bool {
+	logsMu.RLock()
+	defer logsMu.RUnlock()
+
+	return loggedChannels[channel]
+}
+
+// IsLogOptOut reports wheather the user with the twitch ID userID opted out
+// of logging.
+func IsLogOptOut(userID string) bool {
+	logsMu.RLock()
+	defer logsMu.RUnlock()
+
+	return logOptOuts[userID]
+}
@@ -4,6 +4,10 @@ import (
 	"regexp"
 )
 
+func init() {
+	Register(PriorityLow, newMarcsAgeAction())
+}
+
 type marcsAgeAction struct {
 	options *Options
 }
@@ -11,8 +15,10 @@ type marcsAgeAction struct {
 func newMarcsAgeAction() *marcsAgeAction {
 	return &marcsAgeAction{
 		options: &Options{
-			Name: "marcs age",
-			Re:   regexp.MustCompile(`(?i)(\bmarc alter\b)|(\balter marc\b)`),
+			Name:        "marcs age",
+			Re:          regexp.MustCompile(`(?i)(\bmarc alter\b)|(\balter marc\b)`),
+			Description: "Tells you how old marc is.",
+			Usage:       "alter marc",
 		},
 	}
 }
@@ -23,7 +29,7 @@ func (a marcsAgeAction) GetOptions() *Options {
 
 func (a marcsAgeAction) Run(e *Event) error {
 	e.Log.Info().Msg("Gratulating marc for his birthday")
-	e.Say("Marc is heute 16 geworden FeelsBirthdayMan Clap")
+	e.Say(e.T("age.marc"))
 
 	return nil
 }
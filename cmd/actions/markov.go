@@ -0,0 +1,119 @@
+package actions
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/chronophylos/chb3/config"
+	"github.com/chronophylos/chb3/markov"
+)
+
+func init() {
+	Register(PriorityNormal, newMarkovAction())
+}
+
+// Limits of generated messages. Messages refused by the outbound filter or
+// matching a filter rule of the channel are generated again up to
+// MarkovTries times.
+const (
+	MarkovWords = 30
+	MarkovTries = 5
+)
+
+type markovAction struct {
+	options *Options
+}
+
+func newMarkovAction() *markovAction {
+	return &markovAction{
+		options: &Options{
+			Name: "markov",
+			Cmd: &Command{
+				Name: "markov",
+				Args: []Arg{{Name: "words", Type: ArgRest, Optional: true}},
+			},
+			Usage: "~markov [user] [seed word]",
+			Description: "Generates a message from what was written in this channel or by a user. " +
+				"The message starts with the seed word if one is given. Only messages in channels with logging turned on are learned.",
+			Examples: []string{"~markov", "~markov pizza", "~markov someone", "~markov someone pizza"},
+		},
+	}
+}
+
+func (a markovAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a markovAction) Run(e *Event) error {
+	if e.Markov == nil {
+		e.Say(e.T("markov.empty"))
+		return nil
+	}
+
+	key := markov.ChannelKey(e.Msg.Channel)
+	var seed string
+
+	fields := strings.Fields(e.Args.String("words"))
+	if len(fields) > 2 {
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+	if len(fields) > 0 {
+		name := strings.ToLower(strings.TrimPrefix(fields[0], "@"))
+
+		// The first word is a user if the bot learned something from them
+		user, err := e.State.GetUserByName(name)
+		if err == nil && e.Markov.Chain(markov.UserKey(user.ID)) != nil {
+			key = markov.UserKey(user.ID)
+			fields = fields[1:]
+		} else if len(fields) == 2 {
+			e.Say(e.T("markov.unknown", fields[0]))
+			return nil
+		}
+	}
+	if len(fields) > 0 {
+		seed = fields[0]
+	}
+
+	chain := e.Markov.Chain(key)
+	if chain == nil {
+		e.Say(e.T("markov.empty"))
+		return nil
+	}
+	if seed != "" && !chain.Knows(seed) {
+		e.Say(e.T("markov.unknown", seed))
+		return nil
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < MarkovTries; i++ {
+		text := chain.Generate(seed, MarkovWords, rnd)
+		if text == "" {
+			break
+		}
+
+		if IsFiltered(e.Msg.Channel, text) {
+			e.Log.Debug().
+				Str("text", text).
+				Msg("Generated message is filtered")
+			continue
+		}
+
+		checked, err := config.Get().Outbound.Check(text)
+		if err != nil {
+			e.Log.Debug().
+				Err(err).
+				Str("text", text).
+				Msg("Generated message was refused")
+			continue
+		}
+
+		e.Say(checked)
+		return nil
+	}
+
+	e.Say(e.T("markov.failed"))
+
+	return nil
+}
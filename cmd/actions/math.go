@@ -2,11 +2,14 @@ package actions
 
 import (
 	"fmt"
-	"regexp"
 
 	"github.com/Knetic/govaluate"
 )
 
+func init() {
+	Register(PriorityNormal, newMathAction())
+}
+
 type mathAction struct {
 	options *Options
 }
@@ -15,7 +18,13 @@ func newMathAction() *mathAction {
 	return &mathAction{
 		options: &Options{
 			Name: "math",
-			Re:   regexp.MustCompile(`(?i)^~(math|quickmafs) (.*)`),
+			Cmd: &Command{
+				Name:    "math",
+				Aliases: []string{"quickmafs"},
+				Args:    []Arg{{Name: "expression", Type: ArgRest}},
+			},
+			Description: "Calculates an expression.",
+			Examples:    []string{"~math 2 * (3 + 4)"},
 		},
 	}
 }
@@ -25,7 +34,7 @@ func (a mathAction) GetOptions() *Options {
 }
 
 func (a mathAction) Run(e *Event) error {
-	exprString := e.Match[2]
+	exprString := e.Args.String("expression")
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -33,13 +42,13 @@ func (a mathAction) Run(e *Event) error {
 				Str("expression", exprString).
 				Msg("failed to do math")
 
-			e.Say("I can't calculate that :(")
+			e.Say(e.T("math.failed"))
 		}
 	}()
 
 	expr, err := govaluate.NewEvaluableExpression(exprString)
 	if err != nil {
-		e.Say(fmt.Sprintf("Error: %v", err))
+		e.Say(e.T("math.error", err))
 		return err
 	}
 
@@ -4,6 +4,10 @@ import (
 	"regexp"
 )
 
+func init() {
+	Register(PriorityLow, newMaxikingsAgeAction())
+}
+
 type maxikingsAgeAction struct {
 	options *Options
 }
@@ -11,8 +15,10 @@ type maxikingsAgeAction struct {
 func newMaxikingsAgeAction() *maxikingsAgeAction {
 	return &maxikingsAgeAction{
 		options: &Options{
-			Name: "maxikings age",
-			Re:   regexp.MustCompile(`(?i)\balter maxiking\b`),
+			Name:        "maxikings age",
+			Re:          regexp.MustCompile(`(?i)\balter maxiking\b`),
+			Description: "Tells you how old maxiking is.",
+			Usage:       "alter maxiking",
 		},
 	}
 }
@@ -23,7 +29,7 @@ func (a maxikingsAgeAction) GetOptions() *Options {
 
 func (a maxikingsAgeAction) Run(e *Event) error {
 	e.Log.Info().Msg("Checking Maxikings age")
-	e.Say("Maxiking is still underage PepeLaugh")
+	e.Say(e.T("age.maxiking"))
 
 	return nil
 }
@@ -0,0 +1,125 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chronophylos/chb3/spam"
+)
+
+func init() {
+	Register(PriorityNormal, newModerationAction())
+}
+
+type moderationAction struct {
+	options *Options
+}
+
+func newModerationAction() *moderationAction {
+	return &moderationAction{
+		options: &Options{
+			Name: "moderation",
+			Cmd: &Command{
+				Name: "moderation",
+				Args: []Arg{
+					{Name: "setting", Type: ArgWord, Optional: true},
+					{Name: "value", Type: ArgWord, Optional: true},
+				},
+			},
+			Perm:      Broadcaster,
+			Sleepless: true,
+			Usage:     "~moderation [on|off] | ~moderation <" + strings.Join(spam.Checks, "|") + "> <on|off>",
+			Description: "Turns spam moderation in this channel on or off or shows its settings. " +
+				"Users get warned, then timed out for a minute and then for ten minutes. Only works if the bot is a moderator.",
+			Examples: []string{"~moderation", "~moderation on", "~moderation caps off"},
+		},
+	}
+}
+
+func (a moderationAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a moderationAction) Run(e *Event) error {
+	channel, err := e.State.GetChannel(e.Msg.Channel)
+	if err != nil {
+		return fmt.Errorf("getting channel: %v", err)
+	}
+	moderation := channel.Moderation
+
+	setting := strings.ToLower(e.Args.String("setting"))
+	value := strings.ToLower(e.Args.String("value"))
+
+	switch {
+	case setting == "":
+		a.status(e, channel.Moderation.Enabled, moderation.Disabled)
+		return nil
+	case value == "" && (setting == "on" || setting == "off"):
+		moderation.Enabled = setting == "on"
+	case value == "on" || value == "off":
+		if !isCheck(setting) {
+			e.Say(e.T("moderation.unknown", setting, strings.Join(spam.Checks, ", ")))
+			return nil
+		}
+
+		disabled := []string{}
+		for _, check := range moderation.Disabled {
+			if check != setting {
+				disabled = append(disabled, check)
+			}
+		}
+		if value == "off" {
+			disabled = append(disabled, setting)
+		}
+		moderation.Disabled = disabled
+	default:
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	e.Log.Info().
+		Bool("enabled", moderation.Enabled).
+		Strs("disabled", moderation.Disabled).
+		Msg("Changing moderation")
+
+	if err := e.State.SetChannelModeration(e.Msg.Channel, moderation); err != nil {
+		return fmt.Errorf("setting moderation: %v", err)
+	}
+
+	a.status(e, moderation.Enabled, moderation.Disabled)
+
+	return nil
+}
+
+func (a moderationAction) status(e *Event, enabled bool, disabled []string) {
+	if !enabled {
+		e.Say(e.T("moderation.off"))
+		return
+	}
+
+	checks := []string{}
+	for _, check := range spam.Checks {
+		if !contains(disabled, check) {
+			checks = append(checks, check)
+		}
+	}
+
+	if len(checks) == 0 {
+		e.Say(e.T("moderation.on", "-"))
+		return
+	}
+	e.Say(e.T("moderation.on", strings.Join(checks, ", ")))
+}
+
+func isCheck(name string) bool {
+	return contains(spam.Checks, name)
+}
+
+func contains(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package actions
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/chronophylos/chb3/filter"
+)
+
+func init() {
+	Register(PriorityHigh, newNukeAction())
+	Register(PriorityHigh, newUnnukeAction())
+}
+
+// Defaults of ~nuke.
+const (
+	DefaultNukeLookback = time.Minute
+	DefaultNukeTimeout  = 10 * time.Minute
+	// MinNukeLetters is how many letters a phrase needs so a nuke does not
+	// hit everyone.
+	MinNukeLetters = 3
+)
+
+type nukeAction struct {
+	options *Options
+}
+
+func newNukeAction() *nukeAction {
+	return &nukeAction{
+		options: &Options{
+			Name: "nuke",
+			Cmd: &Command{
+				Name: "nuke",
+				Args: []Arg{{Name: "phrase", Type: ArgRest}},
+			},
+			Perm:      Moderator,
+			Sleepless: true,
+			Usage:     "~nuke [--dry] <phrase or /regex/> [lookback] [timeout]",
+			Description: "Times out everyone who wrote the phrase in the last minute or the given lookback. " +
+				"Timeouts last 10 minutes unless a timeout is given. Durations need a unit like 30s or 5m. Moderators are never timed out. " +
+				"Phrases match whole words and need at least three letters, regexes must not match shorter texts. With --dry only the number of users is reported.",
+			Examples: []string{"~nuke buy followers", "~nuke /fr[ie]+ck/ 5m 1h", "~nuke --dry buy followers 2m"},
+		},
+	}
+}
+
+func (a nukeAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a nukeAction) Run(e *Event) error {
+	args, ok := parseNuke(e.Args.String("phrase"))
+	if !ok {
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	match := func(text string) bool {
+		return filter.HasPhrase(text, args.pattern)
+	}
+	if args.regex {
+		re, err := regexp.Compile("(?i)" + args.pattern)
+		if err != nil {
+			e.Say(e.T("filter.invalid", err))
+			return nil
+		}
+		match = func(text string) bool {
+			return re.MatchString(filter.Fold(text))
+		}
+	}
+
+	if !args.dry && !e.Twitch.IsModerator(e.Msg.Channel) {
+		e.Say(e.T("nuke.notmod"))
+		return nil
+	}
+
+	var users []string
+	seen := map[string]bool{}
+	for _, msg := range e.History.Since(e.Msg.Channel, e.Msg.Time.Add(-args.lookback)) {
+		if msg.Mod || msg.UserName == e.BotName || seen[msg.UserName] {
+			continue
+		}
+		if match(msg.Text) {
+			seen[msg.UserName] = true
+			users = append(users, msg.UserName)
+		}
+	}
+
+	e.Log.Info().
+		Str("pattern", args.pattern).
+		Bool("regex", args.regex).
+		Dur("lookback", args.lookback).
+		Dur("timeout", args.timeout).
+		Bool("dry", args.dry).
+		Int("users", len(users)).
+		Msg("Nuking")
+
+	if args.dry {
+		e.Say(e.Plural("nuke.dry", len(users), formatDuration(e, args.lookback)))
+		return nil
+	}
+
+	if len(users) == 0 {
+		e.Say(e.T("nuke.none"))
+		return nil
+	}
+
+	for _, user := range users {
+		e.Command(fmt.Sprintf("/timeout %s %d nuked", user, int(args.timeout.Seconds())))
+	}
+	SetNuke(e.Msg.Channel, users)
+
+	e.Say(e.Plural("nuke.done", len(users), formatDuration(e, args.timeout)))
+
+	return nil
+}
+
+type nukeArgs struct {
+	pattern  string
+	regex    bool
+	lookback time.Duration
+	timeout  time.Duration
+	dry      bool
+}
+
+// parseNuke reads `[--dry] <phrase> [lookback] [timeout]`. Up to two trailing
+// durations with a unit are split from the phrase. Phrases with less than
+// MinNukeLetters letters and regexes matching shorter texts are refused.
+func parseNuke(s string) (nukeArgs, bool) {
+	args := nukeArgs{
+		lookback: DefaultNukeLookback,
+		timeout:  DefaultNukeTimeout,
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) > 0 && (fields[0] == "--dry" || fields[0] == "-n") {
+		args.dry = true
+		fields = fields[1:]
+	}
+
+	var durations []time.Duration
+	for len(fields) > 1 && len(durations) < 2 && hasDurationUnit(fields[len(fields)-1]) {
+		d, err := ParseDuration(fields[len(fields)-1])
+		if err != nil || d <= 0 {
+			break
+		}
+		durations = append([]time.Duration{d}, durations...)
+		fields = fields[:len(fields)-1]
+	}
+	if len(durations) > 0 {
+		args.lookback = durations[0]
+	}
+	if len(durations) > 1 {
+		args.timeout = durations[1]
+	}
+
+	if len(fields) == 0 {
+		return args, false
+	}
+	args.pattern, args.regex = parsePattern(strings.Join(fields, " "))
+
+	if args.regex {
+		// Regexes like /./ would match every message
+		re, err := syntax.Parse("(?i)"+args.pattern, syntax.Perl)
+		if err != nil || minMatchLen(re.Simplify()) < MinNukeLetters {
+			return args, false
+		}
+	} else if countLetters(filter.Fold(args.pattern)) < MinNukeLetters {
+		return args, false
+	}
+
+	return args, true
+}
+
+// minMatchLen returns the number of characters the shortest text matched by
+// re has.
+func minMatchLen(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return math.MaxInt32
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return 1
+	case syntax.OpCapture, syntax.OpPlus:
+		return minMatchLen(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min * minMatchLen(re.Sub[0])
+	case syntax.OpConcat:
+		var n int
+		for _, sub := range re.Sub {
+			n += minMatchLen(sub)
+		}
+		return n
+	case syntax.OpAlternate:
+		n := math.MaxInt32
+		for _, sub := range re.Sub {
+			if m := minMatchLen(sub); m < n {
+				n = m
+			}
+		}
+		return n
+	default:
+		// Empty matches, anchors, word boundaries, stars and quests
+		return 0
+	}
+}
+
+func countLetters(s string) int {
+	var n int
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	return n
+}
+
+type unnukeAction struct {
+	options *Options
+}
+
+func newUnnukeAction() *unnukeAction {
+	return &unnukeAction{
+		options: &Options{
+			Name: "unnuke",
+			Cmd: &Command{
+				Name: "unnuke",
+			},
+			Perm:        Moderator,
+			Sleepless:   true,
+			Description: "Removes the timeouts of the last nuke in this channel.",
+			Examples:    []string{"~unnuke"},
+		},
+	}
+}
+
+func (a unnukeAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a unnukeAction) Run(e *Event) error {
+	users := TakeNuke(e.Msg.Channel)
+	if len(users) == 0 {
+		e.Say(e.T("unnuke.missing"))
+		return nil
+	}
+
+	e.Log.Info().
+		Int("users", len(users)).
+		Msg("Reversing nuke")
+
+	for _, user := range users {
+		e.Command("/untimeout " + user)
+	}
+
+	e.Say(e.Plural("unnuke.done", len(users)))
+
+	return nil
+}
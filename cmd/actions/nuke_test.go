@@ -0,0 +1,38 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNuke(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want nukeArgs
+		ok   bool
+	}{
+		{"buy followers", nukeArgs{pattern: "buy followers", lookback: DefaultNukeLookback, timeout: DefaultNukeTimeout}, true},
+		{"buy followers 5m", nukeArgs{pattern: "buy followers", lookback: 5 * time.Minute, timeout: DefaultNukeTimeout}, true},
+		{"/fr[ie]+ck/ 30s 1h", nukeArgs{pattern: "fr[ie]+ck", regex: true, lookback: 30 * time.Second, timeout: time.Hour}, true},
+		{"top 5", nukeArgs{pattern: "top 5", lookback: DefaultNukeLookback, timeout: DefaultNukeTimeout}, true},
+		{"top 5 10m", nukeArgs{pattern: "top 5", lookback: 10 * time.Minute, timeout: DefaultNukeTimeout}, true},
+		{"--dry 1d", nukeArgs{pattern: "1d", lookback: DefaultNukeLookback, timeout: DefaultNukeTimeout, dry: true}, false},
+		{"1", nukeArgs{pattern: "1", lookback: DefaultNukeLookback, timeout: DefaultNukeTimeout}, false},
+		{"?! 5m", nukeArgs{pattern: "?!", lookback: 5 * time.Minute, timeout: DefaultNukeTimeout}, false},
+		{"tit 5m", nukeArgs{pattern: "tit", lookback: 5 * time.Minute, timeout: DefaultNukeTimeout}, true},
+		{"--dry", nukeArgs{lookback: DefaultNukeLookback, timeout: DefaultNukeTimeout, dry: true}, false},
+		{"/./", nukeArgs{pattern: ".", regex: true, lookback: DefaultNukeLookback, timeout: DefaultNukeTimeout}, false},
+		{"/a*/", nukeArgs{pattern: "a*", regex: true, lookback: DefaultNukeLookback, timeout: DefaultNukeTimeout}, false},
+		{"/ab|c/", nukeArgs{pattern: "ab|c", regex: true, lookback: DefaultNukeLookback, timeout: DefaultNukeTimeout}, false},
+		{"/bu(y|ying) f/", nukeArgs{pattern: "bu(y|ying) f", regex: true, lookback: DefaultNukeLookback, timeout: DefaultNukeTimeout}, true},
+		{"/(/", nukeArgs{pattern: "(", regex: true, lookback: DefaultNukeLookback, timeout: DefaultNukeTimeout}, false},
+	}
+
+	for _, test := range tests {
+		got, ok := parseNuke(test.in)
+		assert.Equal(t, test.ok, ok, test.in)
+		assert.Equal(t, test.want, got, test.in)
+	}
+}
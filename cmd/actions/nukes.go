@@ -0,0 +1,32 @@
+package actions
+
+import (
+	"sync"
+)
+
+// Nukes are the users timed out by the last nuke of every channel so it can
+// be reversed. They are not persisted.
+var (
+	nukesMu sync.Mutex
+	nukes   = map[string][]string{}
+)
+
+// SetNuke remembers users as the users timed out by the last nuke in channel.
+func SetNuke(channel string, users []string) {
+	nukesMu.Lock()
+	defer nukesMu.Unlock()
+
+	nukes[channel] = users
+}
+
+// TakeNuke returns the users timed out by the last nuke in channel and
+// forgets them.
+func TakeNuke(channel string) []string {
+	nukesMu.Lock()
+	defer nukesMu.Unlock()
+
+	users := nukes[channel]
+	delete(nukes, channel)
+
+	return users
+}
@@ -7,6 +7,11 @@ import (
 	"github.com/chronophylos/chb3/state"
 )
 
+func init() {
+	Register(PriorityNormal, newPatscheckAction())
+	Register(PriorityLow, newPatschAction())
+}
+
 type patscheckAction struct {
 	options *Options
 }
@@ -14,8 +19,10 @@ type patscheckAction struct {
 func newPatscheckAction() *patscheckAction {
 	return &patscheckAction{
 		options: &Options{
-			Name: "patsch.check",
-			Re:   regexp.MustCompile(`(?i)^~hihsg\??`),
+			Name:        "patsch.check",
+			Cmd:         &Command{Name: "hihsg", Aliases: []string{"hihsg?"}},
+			Description: "Shows your fish patting streak.",
+			Examples:    []string{"~hihsg"},
 		},
 	}
 }
@@ -31,7 +38,7 @@ func (a patscheckAction) Run(e *Event) error {
 	}
 
 	if user.PatschCount == 0 {
-		e.Say("You have never patted the fish before. You should do that now!")
+		e.Say(e.T("patsch.never"))
 		return nil
 	}
 
@@ -42,26 +49,20 @@ func (a patscheckAction) Run(e *Event) error {
 
 	var message string
 
-	message = "You "
 	if user.HasPatschedToday(e.Msg.Time) {
-		message += "already"
+		message = e.T("patsch.today")
 	} else {
-		message += "have not yet"
+		message = e.T("patsch.not_today")
 	}
-	message += " patted today. "
+	message += " "
 
 	if user.PatschStreak == 0 {
-		message += "You don't have a streak ongoing"
+		message += e.T("patsch.no_streak")
 	} else {
-		message += fmt.Sprintf("Your current streak is %d", user.PatschStreak)
+		message += e.T("patsch.streak", user.PatschStreak)
 	}
 
-	message += " and in total you have patted "
-	if user.PatschCount == 1 {
-		message += "once."
-	} else {
-		message += fmt.Sprintf("%d times.", user.PatschCount)
-	}
+	message += e.Plural("patsch.total", user.PatschCount)
 
 	e.Say(message)
 
@@ -75,8 +76,11 @@ type patschAction struct {
 func newPatschAction() *patschAction {
 	return &patschAction{
 		options: &Options{
-			Name: "patsch.patsch",
-			Re:   regexp.MustCompile(`fischPatsch|fishPat`),
+			Name:        "patsch.patsch",
+			Re:          regexp.MustCompile(`fischPatsch|fishPat`),
+			Description: "Counts your daily fish pats in furzbarts channel.",
+			Usage:       "fischPatsch",
+			Examples:    []string{"fischPatsch"},
 		},
 	}
 }
@@ -93,13 +97,13 @@ func (a patschAction) Run(e *Event) error {
 	e.Log.Info().Msg("Patsch!")
 
 	if len(e.Match) > 1 {
-		e.Say("/timeout " + e.Msg.User.Name + " 1 Wenn du so viel patschst wird das ne Flunder")
+		e.Command("/timeout " + e.Msg.User.Name + " 1 " + e.T("patsch.flunder"))
 		return nil
 	}
 
 	if err := e.State.Patsch(e.Msg.User.ID, e.Msg.Time); err != nil {
 		if err == state.ErrAlreadyPatsched {
-			e.Say("Du hast heute schon gepatscht")
+			e.Say(e.T("patsch.already"))
 			return nil
 		} else if err == state.ErrForgotToPatsch {
 			return nil
@@ -0,0 +1,59 @@
+package actions
+
+import (
+	"time"
+)
+
+func init() {
+	Register(PriorityNormal, newPermitAction())
+}
+
+// DefaultPermit is how long a permit lasts if no duration is given.
+const DefaultPermit = time.Minute
+
+type permitAction struct {
+	options *Options
+}
+
+func newPermitAction() *permitAction {
+	return &permitAction{
+		options: &Options{
+			Name: "permit",
+			Cmd: &Command{
+				Name: "permit",
+				Args: []Arg{
+					{Name: "user", Type: ArgUser},
+					{Name: "duration", Type: ArgDuration, Optional: true},
+				},
+			},
+			Perm:        Moderator,
+			Sleepless:   true,
+			Description: "Allows a user to post links for a minute or the given duration.",
+			Examples:    []string{"~permit someone", "~permit someone 5m"},
+		},
+	}
+}
+
+func (a permitAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a permitAction) Run(e *Event) error {
+	user := e.Args.String("user")
+
+	d := DefaultPermit
+	if e.Args.Has("duration") {
+		d = e.Args.Duration("duration")
+	}
+
+	e.Log.Info().
+		Str("user", user).
+		Dur("duration", d).
+		Msg("Permitting links")
+
+	Permit(e.Msg.Channel, user, e.Msg.Time.Add(d))
+
+	e.Say(e.T("permit.done", user, formatDuration(e, d)))
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package actions
+
+import (
+	"sync"
+	"time"
+)
+
+// Permits allow users to post links in a channel with spam moderation for a
+// short time. They are not persisted.
+var (
+	permitsMu sync.Mutex
+	permits   = map[permitKey]time.Time{}
+)
+
+type permitKey struct {
+	channel string
+	user    string
+}
+
+// Permit allows user to post links in channel until until.
+func Permit(channel, user string, until time.Time) {
+	permitsMu.Lock()
+	defer permitsMu.Unlock()
+
+	now := time.Now()
+	for key, u := range permits {
+		if now.After(u) {
+			delete(permits, key)
+		}
+	}
+
+	permits[permitKey{channel, user}] = until
+}
+
+// IsPermitted reports wheather user may post links in channel at t.
+func IsPermitted(channel, user string, t time.Time) bool {
+	permitsMu.Lock()
+	defer permitsMu.Unlock()
+
+	until, ok := permits[permitKey{channel, user}]
+	return ok && t.Before(until)
+}
@@ -1,14 +1,16 @@
 package actions
 
 import (
-	"fmt"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/chronophylos/chb3/util"
 )
 
+func init() {
+	Register(PriorityNormal, newPingAction())
+}
+
 type pingAction struct {
 	options *Options
 	created time.Time
@@ -17,8 +19,10 @@ type pingAction struct {
 func newPingAction() *pingAction {
 	return &pingAction{
 		options: &Options{
-			Name: "ping",
-			Re:   regexp.MustCompile(`(?i)^~ping`),
+			Name:        "ping",
+			Cmd:         &Command{Name: "ping"},
+			Description: "Shows how long the bot has been running, how often it reconnected and how long your message took.",
+			Examples:    []string{"~ping"},
 		},
 		created: time.Now(),
 	}
@@ -29,16 +33,22 @@ func (a pingAction) GetOptions() *Options {
 }
 
 func (a pingAction) Run(e *Event) error {
-	e.Say(fmt.Sprintf("I've been running for %s. It took %dms to receive your message.",
-		formatDuration(e.Msg.Time.Sub(a.created)),
+	reply := e.T("ping.reply",
+		formatDuration(e, e.Msg.Time.Sub(a.created)),
 		time.Since(e.Msg.Time).Milliseconds(),
-	))
+	)
+
+	if n := e.Twitch.Reconnects(); n > 0 {
+		reply += " " + e.Plural("ping.reconnects", int(n))
+	}
+
+	e.Say(reply)
 
 	return nil
 }
 
-func formatDuration(d time.Duration) string {
-	var strs strings.Builder
+func formatDuration(e *Event, d time.Duration) string {
+	var strs []string
 	var seconds, minutes, hours, days, weeks, months, years int
 	var temp float64
 
@@ -51,26 +61,20 @@ func formatDuration(d time.Duration) string {
 
 	years = int(temp)
 
-	formatToBuilder(&strs, "year", years)
-	formatToBuilder(&strs, "month", months)
-	formatToBuilder(&strs, "week", weeks)
-	formatToBuilder(&strs, "day", days)
-	formatToBuilder(&strs, "hour", hours)
-	formatToBuilder(&strs, "minute", minutes)
-	formatToBuilder(&strs, "second", seconds)
-
-	return strings.TrimSpace(strs.String())
-}
+	strs = appendUnit(e, strs, "year", years)
+	strs = appendUnit(e, strs, "month", months)
+	strs = appendUnit(e, strs, "week", weeks)
+	strs = appendUnit(e, strs, "day", days)
+	strs = appendUnit(e, strs, "hour", hours)
+	strs = appendUnit(e, strs, "minute", minutes)
+	strs = appendUnit(e, strs, "second", seconds)
 
-func formatToBuilder(b *strings.Builder, s string, c int) {
-	if c > 1 {
-		b.WriteString(pluralize(s, c))
-	}
+	return strings.Join(strs, " ")
 }
 
-func pluralize(s string, c int) string {
-	if c >= 2 {
-		s = s + "s"
+func appendUnit(e *Event, strs []string, unit string, c int) []string {
+	if c > 0 {
+		strs = append(strs, e.Plural("duration."+unit, c))
 	}
-	return fmt.Sprintf("%d %s ", c, s)
+	return strs
 }
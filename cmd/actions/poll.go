@@ -0,0 +1,231 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chronophylos/chb3/i18n"
+	"github.com/chronophylos/chb3/poll"
+	"github.com/chronophylos/chb3/state"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	Register(PriorityNormal, newPollAction())
+}
+
+// Limits of ~poll.
+const (
+	DefaultPollDuration = 2 * time.Minute
+	MinPollDuration     = 10 * time.Second
+	MaxPollDuration     = time.Hour
+	MaxPollOptions      = 10
+)
+
+type pollAction struct {
+	options *Options
+}
+
+func newPollAction() *pollAction {
+	return &pollAction{
+		options: &Options{
+			Name: "poll",
+			Cmd: &Command{
+				Name: "poll",
+				Args: []Arg{{Name: "poll", Type: ArgRest, Optional: true}},
+			},
+			Usage: `~poll "question" option | option [| option...] [duration] | ~poll | ~poll end | ~poll last`,
+			Description: "Starts a poll that lasts two minutes or the given duration with a unit like 90s or 5m. Chatters vote by writing the number or the text of an option. " +
+				"Without arguments the current standings are shown. Only moderators can start and end polls.",
+			Examples: []string{`~poll "Pizza or pasta?" pizza | pasta`, `~poll "Which game next?" Minecraft | Celeste | Doom 5m`, "~poll end", "~poll last"},
+		},
+	}
+}
+
+func (a pollAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a pollAction) Run(e *Event) error {
+	rest := strings.TrimSpace(e.Args.String("poll"))
+
+	switch strings.ToLower(rest) {
+	case "":
+		current, ok := e.Polls.Current(e.Msg.Channel)
+		if !ok {
+			e.Say(e.T("poll.none"))
+			return nil
+		}
+		e.Say(PollStandings(e.Language, current))
+		return nil
+	case "last":
+		return a.last(e)
+	case "end", "stop":
+		if !e.HasPermission(Moderator) {
+			e.Say(e.T("poll.forbidden"))
+			return nil
+		}
+		if !e.Polls.End(e.Msg.Channel) {
+			e.Say(e.T("poll.none"))
+		}
+		return nil
+	}
+
+	if !e.HasPermission(Moderator) {
+		e.Say(e.T("poll.forbidden"))
+		return nil
+	}
+
+	question, options, d, ok := parsePoll(rest)
+	if !ok {
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	result, err := e.Polls.Start(e.Msg.Channel, e.Msg.User.Name, question, options, e.Msg.Time, d)
+	if err == poll.ErrRunning {
+		e.Say(e.T("poll.running"))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("starting poll: %v", err)
+	}
+
+	e.Log.Info().
+		Str("question", question).
+		Strs("options", options).
+		Dur("duration", d).
+		Msg("Started poll")
+
+	e.Say(e.T("poll.started", result.Question, formatPollOptions(result.Options), formatDuration(e, d)))
+
+	return nil
+}
+
+func (a pollAction) last(e *Event) error {
+	last, err := e.State.GetLastPoll(e.Msg.Channel)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			e.Say(e.T("poll.nolast"))
+			return nil
+		}
+		return fmt.Errorf("getting last poll: %v", err)
+	}
+
+	e.Say(PollResult(e.Language, poll.Result{
+		Channel:  last.Channel,
+		Question: last.Question,
+		Options:  last.Options,
+		Counts:   last.Counts,
+		Voters:   last.Voters,
+		By:       last.By,
+		Started:  last.Started,
+		Ends:     last.Ended,
+	}))
+
+	return nil
+}
+
+// parsePoll reads `"question" option | option [duration]`. The duration needs
+// a unit.
+func parsePoll(s string) (question string, options []string, d time.Duration, ok bool) {
+	d = DefaultPollDuration
+
+	if !strings.HasPrefix(s, `"`) {
+		return "", nil, d, false
+	}
+	end := strings.Index(s[1:], `"`)
+	if end < 0 {
+		return "", nil, d, false
+	}
+	question = strings.TrimSpace(s[1 : end+1])
+
+	for _, option := range strings.Split(s[end+2:], "|") {
+		options = append(options, strings.TrimSpace(option))
+	}
+
+	// The duration is the last word of the last option. It needs a unit so
+	// options ending with a number stay intact.
+	last := strings.Fields(options[len(options)-1])
+	if len(last) > 1 && hasDurationUnit(last[len(last)-1]) {
+		if parsed, err := ParseDuration(last[len(last)-1]); err == nil {
+			d = parsed
+			options[len(options)-1] = strings.Join(last[:len(last)-1], " ")
+		}
+	}
+	if d < MinPollDuration {
+		d = MinPollDuration
+	}
+	if d > MaxPollDuration {
+		d = MaxPollDuration
+	}
+
+	for _, option := range options {
+		if option == "" {
+			return "", nil, d, false
+		}
+	}
+
+	return question, options, d, question != "" && len(options) >= 2 && len(options) <= MaxPollOptions
+}
+
+func formatPollOptions(options []string) string {
+	strs := make([]string, len(options))
+	for i, option := range options {
+		strs[i] = fmt.Sprintf("%d) %s", i+1, option)
+	}
+	return strings.Join(strs, ", ")
+}
+
+func formatPollCounts(r poll.Result) string {
+	strs := make([]string, len(r.Options))
+	for i, option := range r.Options {
+		var percent int
+		if r.Voters > 0 {
+			percent = r.Counts[i] * 100 / r.Voters
+		}
+		strs[i] = fmt.Sprintf("%d) %s: %d (%d%%)", i+1, option, r.Counts[i], percent)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// PollStandings formats the live standings of r in lang.
+func PollStandings(lang string, r poll.Result) string {
+	return i18n.T(lang, "poll.standings", r.Question, formatPollCounts(r))
+}
+
+// PollResult formats the final result of r in lang.
+func PollResult(lang string, r poll.Result) string {
+	winners := r.Winners()
+
+	var verdict string
+	switch len(winners) {
+	case 0:
+		verdict = i18n.T(lang, "poll.novotes")
+	case 1:
+		verdict = i18n.T(lang, "poll.winner", r.Options[winners[0]])
+	default:
+		names := make([]string, len(winners))
+		for i, w := range winners {
+			names[i] = r.Options[w]
+		}
+		verdict = i18n.T(lang, "poll.tie", strings.Join(names, ", "))
+	}
+
+	return i18n.T(lang, "poll.ended", r.Question, formatPollCounts(r), verdict)
+}
+
+// PollRecord converts the result of an ended poll for storing it.
+func PollRecord(r poll.Result, ended time.Time) state.Poll {
+	return state.Poll{
+		Channel:  r.Channel,
+		Question: r.Question,
+		Options:  r.Options,
+		Counts:   r.Counts,
+		Voters:   r.Voters,
+		By:       r.By,
+		Started:  r.Started,
+		Ended:    ended,
+	}
+}
@@ -0,0 +1,38 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePoll(t *testing.T) {
+	var tests = []struct {
+		in       string
+		question string
+		options  []string
+		d        time.Duration
+		ok       bool
+	}{
+		{`"Pizza or pasta?" pizza | pasta`, "Pizza or pasta?", []string{"pizza", "pasta"}, DefaultPollDuration, true},
+		{`"Next game?" Minecraft | Dark Souls | Doom 5m`, "Next game?", []string{"Minecraft", "Dark Souls", "Doom"}, 5 * time.Minute, true},
+		{`"Which Doom?" Doom | Doom 2`, "Which Doom?", []string{"Doom", "Doom 2"}, DefaultPollDuration, true},
+		{`"Next Doom?" Doom | Doom 2 10m`, "Next Doom?", []string{"Doom", "Doom 2"}, 10 * time.Minute, true},
+		{`"Short?" yes | no 1s`, "Short?", []string{"yes", "no"}, MinPollDuration, true},
+		{`"Only one?" yes`, "Only one?", []string{"yes"}, DefaultPollDuration, false},
+		{`"Empty?" yes | | no`, "", nil, DefaultPollDuration, false},
+		{`No quotes? yes | no`, "", nil, DefaultPollDuration, false},
+		{`"Unclosed? yes | no`, "", nil, DefaultPollDuration, false},
+	}
+
+	for _, test := range tests {
+		question, options, d, ok := parsePoll(test.in)
+		assert.Equal(t, test.ok, ok, test.in)
+		if test.ok {
+			assert.Equal(t, test.question, question, test.in)
+			assert.Equal(t, test.options, options, test.in)
+			assert.Equal(t, test.d, d, test.in)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	Register(PriorityNormal, newPrefixAction())
+}
+
+type prefixAction struct {
+	options *Options
+}
+
+func newPrefixAction() *prefixAction {
+	return &prefixAction{
+		options: &Options{
+			Name: "prefix",
+			Cmd: &Command{
+				Name: "prefix",
+				Args: []Arg{{Name: "prefix", Type: ArgWord}},
+			},
+			Perm:        Broadcaster,
+			Description: "Changes the command prefix of this channel. Use reset to go back to ~.",
+			Examples:    []string{"~prefix !", "~prefix reset"},
+		},
+	}
+}
+
+func (a prefixAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a prefixAction) Run(e *Event) error {
+	prefix := e.Args.String("prefix")
+
+	if prefix == "reset" {
+		prefix = DefaultPrefix
+	}
+
+	// Prefixes starting with / or . would make twitch read replies as
+	// commands.
+	if utf8.RuneCountInString(prefix) > 3 || strings.ContainsAny(prefix[:1], "/.") {
+		e.Say(e.T("prefix.invalid", prefix))
+		return nil
+	}
+
+	e.Log.Info().
+		Str("prefix", prefix).
+		Msg("Setting command prefix")
+
+	if err := e.State.SetChannelPrefix(e.Msg.Channel, prefix); err != nil {
+		return fmt.Errorf("setting channel prefix: %v", err)
+	}
+
+	e.Say(e.T("prefix.set", prefix))
+
+	return nil
+}
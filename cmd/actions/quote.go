@@ -0,0 +1,208 @@
+package actions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chronophylos/chb3/config"
+	"github.com/chronophylos/chb3/state"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	Register(PriorityNormal, newQuoteAction())
+}
+
+// QuoteSearchLimit is the number of quotes listed by `~quote search`.
+const QuoteSearchLimit = 10
+
+type quoteAction struct {
+	options *Options
+}
+
+func newQuoteAction() *quoteAction {
+	return &quoteAction{
+		options: &Options{
+			Name: "quote",
+			Cmd: &Command{
+				Name: "quote",
+				Args: []Arg{
+					{Name: "subcommand", Type: ArgWord, Optional: true},
+					{Name: "rest", Type: ArgRest, Optional: true},
+				},
+			},
+			Usage: "~quote [id] | ~quote random [user] | ~quote add [@user] <text> | ~quote search <text> | ~quote del <id>",
+			Description: "Shows, adds, searches and deletes the quotes of this channel. " +
+				"Moderators can attribute a quote to a user by starting it with @user. Quotes can be deleted by moderators and whoever added them.",
+			Examples: []string{"~quote", "~quote 42", "~quote random someone", "~quote add @someone I'm never wrong", "~quote search wrong", "~quote del 42"},
+		},
+	}
+}
+
+func (a quoteAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a quoteAction) Run(e *Event) error {
+	sub := strings.ToLower(e.Args.String("subcommand"))
+	rest := strings.TrimSpace(e.Args.String("rest"))
+
+	if id, err := strconv.Atoi(sub); err == nil {
+		return a.show(e, id)
+	}
+
+	switch sub {
+	case "", "random":
+		return a.random(e, strings.ToLower(strings.TrimPrefix(rest, "@")))
+	case "add":
+		return a.add(e, rest)
+	case "search":
+		return a.search(e, rest)
+	case "del", "delete", "remove", "rm":
+		return a.remove(e, rest)
+	}
+
+	e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+	return nil
+}
+
+func (a quoteAction) show(e *Event, id int) error {
+	quote, err := e.State.GetQuote(e.Msg.Channel, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			e.Say(e.T("quote.missing", id))
+			return nil
+		}
+		return fmt.Errorf("getting quote: %v", err)
+	}
+
+	e.Say(formatQuote(e, quote))
+
+	return nil
+}
+
+func (a quoteAction) random(e *Event, user string) error {
+	quote, err := e.State.GetRandomQuote(e.Msg.Channel, user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			e.Say(e.T("quote.empty"))
+			return nil
+		}
+		return fmt.Errorf("getting random quote: %v", err)
+	}
+
+	e.Say(formatQuote(e, quote))
+
+	return nil
+}
+
+func (a quoteAction) add(e *Event, text string) error {
+	quote := state.Quote{
+		Channel: e.Msg.Channel,
+		By:      e.Msg.User.Name,
+		ByID:    e.Msg.User.ID,
+		Created: e.Msg.Time,
+	}
+
+	// Only moderators may put words in someone elses mouth
+	if fields := strings.SplitN(text, " ", 2); len(fields) == 2 && strings.HasPrefix(fields[0], "@") && e.HasPermission(Moderator) {
+		quote.User = strings.ToLower(strings.TrimPrefix(fields[0], "@"))
+		text = strings.TrimSpace(fields[1])
+	}
+	quote.Text = text
+
+	if quote.Text == "" {
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	if _, err := config.Get().Outbound.Check(quote.Text); err != nil {
+		e.Say(e.T("quote.refused"))
+		return nil
+	}
+
+	quote, err := e.State.AddQuote(quote)
+	if err != nil {
+		return fmt.Errorf("adding quote: %v", err)
+	}
+
+	e.Log.Info().
+		Int("id", quote.ID).
+		Str("quoted", quote.User).
+		Msg("Added quote")
+
+	e.Say(e.T("quote.added", quote.ID))
+
+	return nil
+}
+
+func (a quoteAction) search(e *Event, text string) error {
+	if text == "" {
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	quotes, err := e.State.SearchQuotes(e.Msg.Channel, text, QuoteSearchLimit)
+	if err != nil {
+		return fmt.Errorf("searching quotes: %v", err)
+	}
+
+	switch len(quotes) {
+	case 0:
+		e.Say(e.T("quote.notfound"))
+	case 1:
+		e.Say(formatQuote(e, quotes[0]))
+	default:
+		ids := make([]string, len(quotes))
+		for i, quote := range quotes {
+			ids[i] = "#" + strconv.Itoa(quote.ID)
+		}
+		e.Say(e.T("quote.found", strings.Join(ids, ", ")))
+	}
+
+	return nil
+}
+
+func (a quoteAction) remove(e *Event, rest string) error {
+	id, err := strconv.Atoi(strings.TrimPrefix(rest, "#"))
+	if err != nil {
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	quote, err := e.State.GetQuote(e.Msg.Channel, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			e.Say(e.T("quote.missing", id))
+			return nil
+		}
+		return fmt.Errorf("getting quote: %v", err)
+	}
+
+	// Names can change and be taken by someone else, IDs can't
+	if !e.HasPermission(Moderator) && quote.ByID != e.Msg.User.ID {
+		e.Say(e.T("quote.forbidden", id))
+		return nil
+	}
+
+	if _, err := e.State.RemoveQuote(e.Msg.Channel, id); err != nil {
+		return fmt.Errorf("removing quote: %v", err)
+	}
+
+	e.Log.Info().
+		Int("id", id).
+		Msg("Removed quote")
+
+	e.Say(e.T("quote.removed", id))
+
+	return nil
+}
+
+func formatQuote(e *Event, quote state.Quote) string {
+	date := quote.Created.UTC().Format("2006-01-02")
+	if quote.User == "" {
+		return e.T("quote.show", quote.ID, quote.Text, date)
+	}
+	return e.T("quote.show_user", quote.ID, quote.Text, quote.User, date)
+}
@@ -0,0 +1,299 @@
+package actions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chronophylos/chb3/i18n"
+	"github.com/chronophylos/chb3/raffle"
+	"github.com/chronophylos/chb3/state"
+)
+
+func init() {
+	Register(PriorityNormal, newRaffleAction())
+}
+
+// MaxRaffleWinners is the number of winners `~raffle draw` draws at most.
+const MaxRaffleWinners = 10
+
+type raffleAction struct {
+	options *Options
+}
+
+func newRaffleAction() *raffleAction {
+	return &raffleAction{
+		options: &Options{
+			Name: "raffle",
+			Cmd: &Command{
+				Name:    "raffle",
+				Aliases: []string{"giveaway"},
+				Args: []Arg{
+					{Name: "subcommand", Type: ArgWord, Optional: true},
+					{Name: "rest", Type: ArgRest, Optional: true},
+				},
+			},
+			Usage: "~raffle start <keyword> [sub-only] [follower-only] [min-account-age <duration>] | ~raffle draw [n] | ~raffle end | ~raffle",
+			Description: "Starts a raffle that chatters enter by writing the keyword. Drawing closes the raffle and picks n winners who have to write in chat " +
+				"to claim the win or somebody else is drawn. Without arguments the current raffle is shown. Only the broadcaster can start, draw and end raffles.",
+			Examples: []string{"~raffle start !join", "~raffle start !join sub-only min-account-age 7d", "~raffle draw", "~raffle draw 3", "~raffle end"},
+		},
+	}
+}
+
+func (a raffleAction) GetOptions() *Options {
+	return a.options
+}
+
+func (a raffleAction) Run(e *Event) error {
+	sub := strings.ToLower(e.Args.String("subcommand"))
+	rest := strings.TrimSpace(e.Args.String("rest"))
+
+	if sub == "" {
+		current, ok := e.Raffles.Current(e.Msg.Channel)
+		if !ok {
+			e.Say(e.T("raffle.none"))
+			return nil
+		}
+		e.Say(RaffleStatus(e.Language, current))
+		return nil
+	}
+
+	switch sub {
+	case "start", "draw", "end", "stop":
+	default:
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	if !e.HasPermission(Broadcaster) {
+		e.Say(e.T("raffle.forbidden"))
+		return nil
+	}
+
+	switch sub {
+	case "start":
+		return a.start(e, rest)
+	case "draw":
+		return a.draw(e, rest)
+	default:
+		r, ok := e.Raffles.End(e.Msg.Channel, e.Msg.Time)
+		if !ok {
+			e.Say(e.T("raffle.none"))
+			return nil
+		}
+		e.Say(e.T("raffle.ended", r.Keyword, e.Plural("raffle.entrants", len(r.Entrants)), formatRaffleWinners(e.Language, r.Winners)))
+		return nil
+	}
+}
+
+func (a raffleAction) start(e *Event, rest string) error {
+	words := strings.Fields(rest)
+	if len(words) == 0 {
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	rules, ok := parseRaffleRules(words[1:])
+	if !ok {
+		e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+		return nil
+	}
+
+	r, err := e.Raffles.Start(e.Msg.Channel, e.Msg.User.Name, words[0], rules, e.Msg.Time)
+	if err == raffle.ErrRunning {
+		e.Say(e.T("raffle.running"))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("starting raffle: %v", err)
+	}
+
+	e.Log.Info().
+		Str("keyword", r.Keyword).
+		Interface("rules", rules).
+		Msg("Started raffle")
+
+	e.Say(e.T("raffle.started", r.Keyword, formatRaffleRules(e.Language, rules)))
+
+	return nil
+}
+
+func (a raffleAction) draw(e *Event, rest string) error {
+	n := 1
+	if rest != "" {
+		var err error
+		n, err = strconv.Atoi(rest)
+		if err != nil || n < 1 {
+			e.Say(e.T("usage", UsageFor(a.options, e.Prefix)))
+			return nil
+		}
+	}
+	if n > MaxRaffleWinners {
+		n = MaxRaffleWinners
+	}
+
+	winners, err := e.Raffles.Draw(e.Msg.Channel, n, e.Msg.Time)
+	switch err {
+	case nil:
+	case raffle.ErrNotRunning:
+		e.Say(e.T("raffle.none"))
+		return nil
+	case raffle.ErrNoEntrants:
+		e.Say(e.T("raffle.empty"))
+		return nil
+	default:
+		return fmt.Errorf("drawing raffle: %v", err)
+	}
+
+	names := make([]string, len(winners))
+	for i, w := range winners {
+		names[i] = "@" + w.UserName
+	}
+
+	e.Log.Info().
+		Strs("winners", names).
+		Msg("Drew raffle winners")
+
+	reply := e.Plural("raffle.drawn", len(winners), strings.Join(names, ", "))
+	if e.Raffles.Timeout > 0 {
+		reply += " " + e.T("raffle.confirm", formatDuration(e, e.Raffles.Timeout))
+	}
+	e.Say(reply)
+
+	return nil
+}
+
+// parseRaffleRules reads `[sub-only] [follower-only] [min-account-age <duration>]`.
+// The duration needs a unit.
+func parseRaffleRules(words []string) (raffle.Rules, bool) {
+	var rules raffle.Rules
+
+	for i := 0; i < len(words); i++ {
+		word := strings.ToLower(words[i])
+
+		switch word {
+		case "sub-only", "subs":
+			rules.SubOnly = true
+			continue
+		case "follower-only", "followers":
+			rules.FollowerOnly = true
+			continue
+		}
+
+		if !strings.HasPrefix(word, "min-account-age") {
+			return rules, false
+		}
+
+		value := strings.TrimPrefix(strings.TrimPrefix(word, "min-account-age"), "=")
+		if value == "" {
+			i++
+			if i == len(words) {
+				return rules, false
+			}
+			value = words[i]
+		}
+
+		if !hasDurationUnit(value) {
+			return rules, false
+		}
+		d, err := ParseDuration(value)
+		if err != nil || d <= 0 {
+			return rules, false
+		}
+		rules.MinAccountAge = d
+	}
+
+	return rules, true
+}
+
+func formatRaffleRules(lang string, rules raffle.Rules) string {
+	var strs []string
+	if rules.SubOnly {
+		strs = append(strs, i18n.T(lang, "raffle.rule_sub"))
+	}
+	if rules.FollowerOnly {
+		strs = append(strs, i18n.T(lang, "raffle.rule_follower"))
+	}
+	if rules.MinAccountAge > 0 {
+		e := &Event{Language: lang}
+		strs = append(strs, i18n.T(lang, "raffle.rule_age", formatDuration(e, rules.MinAccountAge)))
+	}
+
+	if len(strs) == 0 {
+		return i18n.T(lang, "raffle.rules_none")
+	}
+	return i18n.T(lang, "raffle.rules", strings.Join(strs, ", "))
+}
+
+func formatRaffleWinners(lang string, winners []raffle.Winner) string {
+	if len(winners) == 0 {
+		return i18n.T(lang, "raffle.nowinners")
+	}
+
+	strs := make([]string, len(winners))
+	for i, w := range winners {
+		switch {
+		case w.Confirmed:
+			strs[i] = i18n.T(lang, "raffle.winner_confirmed", w.UserName)
+		case w.Expired:
+			strs[i] = i18n.T(lang, "raffle.winner_expired", w.UserName)
+		default:
+			strs[i] = i18n.T(lang, "raffle.winner_pending", w.UserName)
+		}
+	}
+	return strings.Join(strs, ", ")
+}
+
+// RaffleStatus formats the state of r in lang.
+func RaffleStatus(lang string, r raffle.Raffle) string {
+	if !r.Closed {
+		return i18n.T(lang, "raffle.status", r.Keyword, formatRaffleRules(lang, r.Rules), len(r.Entrants))
+	}
+	return i18n.T(lang, "raffle.closed", r.Keyword, i18n.Plural(lang, "raffle.entrants", len(r.Entrants)), formatRaffleWinners(lang, r.Winners))
+}
+
+// RaffleExpired formats the message sent when expired did not confirm in
+// time. next is the winner drawn instead or nil. timeout is how long next
+// has to confirm.
+func RaffleExpired(lang string, expired raffle.Winner, next *raffle.Winner, timeout time.Duration) string {
+	if next == nil {
+		return i18n.T(lang, "raffle.expired_none", "@"+expired.UserName)
+	}
+
+	e := &Event{Language: lang}
+	return i18n.T(lang, "raffle.expired", "@"+expired.UserName, "@"+next.UserName) +
+		" " + i18n.T(lang, "raffle.confirm", formatDuration(e, timeout))
+}
+
+// RaffleRecord converts r for storing it as an audit record.
+func RaffleRecord(r raffle.Raffle) state.Raffle {
+	record := state.Raffle{
+		Channel:       r.Channel,
+		Keyword:       r.Keyword,
+		SubOnly:       r.Rules.SubOnly,
+		FollowerOnly:  r.Rules.FollowerOnly,
+		MinAccountAge: r.Rules.MinAccountAge,
+		By:            r.By,
+		Started:       r.Started,
+		Ended:         r.Ended,
+	}
+	for _, entrant := range r.Entrants {
+		record.Entrants = append(record.Entrants, state.RaffleEntrant{
+			UserID:   entrant.UserID,
+			UserName: entrant.UserName,
+			Time:     entrant.Time,
+		})
+	}
+	for _, w := range r.Winners {
+		record.Winners = append(record.Winners, state.RaffleWinner{
+			UserID:    w.UserID,
+			UserName:  w.UserName,
+			Drawn:     w.Drawn,
+			Confirmed: w.Confirmed,
+			Expired:   w.Expired,
+		})
+	}
+	return record
+}
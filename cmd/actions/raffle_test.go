@@ -0,0 +1,36 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chronophylos/chb3/raffle"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRaffleRules(t *testing.T) {
+	var tests = []struct {
+		in    string
+		rules raffle.Rules
+		ok    bool
+	}{
+		{"", raffle.Rules{}, true},
+		{"sub-only", raffle.Rules{SubOnly: true}, true},
+		{"Follower-Only sub-only", raffle.Rules{SubOnly: true, FollowerOnly: true}, true},
+		{"min-account-age 7d", raffle.Rules{MinAccountAge: 7 * 24 * time.Hour}, true},
+		{"min-account-age=1w followers", raffle.Rules{FollowerOnly: true, MinAccountAge: 7 * 24 * time.Hour}, true},
+		{"min-account-age", raffle.Rules{}, false},
+		{"min-account-age soon", raffle.Rules{}, false},
+		{"min-account-age 7", raffle.Rules{}, false},
+		{"vip-only", raffle.Rules{}, false},
+	}
+
+	for _, test := range tests {
+		rules, ok := parseRaffleRules(strings.Fields(test.in))
+		assert.Equal(t, test.ok, ok, test.in)
+		if test.ok {
+			assert.Equal(t, test.rules, rules, test.in)
+		}
+	}
+}
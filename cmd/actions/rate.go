@@ -2,11 +2,13 @@ package actions
 
 import (
 	"crypto/md5"
-	"fmt"
 	"math/big"
-	"regexp"
 )
 
+func init() {
+	Register(PriorityNormal, newRateAction())
+}
+
 type rateAction struct {
 	options *Options
 }
@@ -15,7 +17,12 @@ func newRateAction() *rateAction {
 	return &rateAction{
 		options: &Options{
 			Name: "rate",
-			Re:   regexp.MustCompile(`(?i)^~rate (.*)`),
+			Cmd: &Command{
+				Name: "rate",
+				Args: []Arg{{Name: "what", Type: ArgRest}},
+			},
+			Description: "Rates anything on a scale from 0 to 10.",
+			Examples:    []string{"~rate pineapple pizza"},
 		},
 	}
 }
@@ -23,7 +30,7 @@ func newRateAction() *rateAction {
 func (a rateAction) GetOptions() *Options { return a.options }
 
 func (a rateAction) Run(e *Event) error {
-	what := e.Match[1]
+	what := e.Args.String("what")
 	rating := rate(what)
 
 	e.Log.Info().
@@ -31,7 +38,7 @@ func (a rateAction) Run(e *Event) error {
 		Float32("rating", rating).
 		Msg("rating")
 
-	e.Say(fmt.Sprintf("I rate %s %.1f/10", what, rating))
+	e.Say(e.T("rate.reply", what, rating))
 
 	return nil
 }
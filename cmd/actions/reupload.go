@@ -10,9 +10,14 @@ import (
 	"strings"
 
 	"github.com/chronophylos/chb3/buildinfo"
+	"github.com/chronophylos/chb3/metrics"
 	"github.com/rs/zerolog/log"
 )
 
+func init() {
+	Register(PriorityLow, newReuploadAction())
+}
+
 type reuploadAction struct {
 	options *Options
 }
@@ -24,6 +29,8 @@ func newReuploadAction() *reuploadAction {
 			Re: regexp.MustCompile(
 				`(https?:\/\/)?((damn-community\.com|screenshots\.relentless\.wtf|puddelgaming\.de\/upload)\/.*\.(png|jpe?g))`,
 			),
+			Description: "Reuploads screenshots of some image hosters to imgur.",
+			Usage:       "<image link>",
 		},
 	}
 }
@@ -47,7 +54,7 @@ func (a reuploadAction) Run(e *Event) error {
 		e.Log.Info().
 			Str("link", link).
 			Msg("Reuploading an image to imgur")
-		e.Say("Did you mean " + newLink + " ?")
+		e.Say(e.T("reupload.reply", newLink))
 	}
 
 	return nil
@@ -62,7 +69,9 @@ type imgurBody struct {
 }
 
 func reupload(link string, clientID, userAgent string) (string, error) {
-	client := &http.Client{}
+	client := &http.Client{
+		Transport: metrics.Transport("imgur", nil),
+	}
 
 	form := url.Values{}
 	form.Add("image", link)
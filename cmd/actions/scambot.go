@@ -4,6 +4,10 @@ import (
 	"regexp"
 )
 
+func init() {
+	Register(PriorityLow, newScambotAction())
+}
+
 type scambotAction struct {
 	options *Options
 }
@@ -11,8 +15,10 @@ type scambotAction struct {
 func newScambotAction() *scambotAction {
 	return &scambotAction{
 		options: &Options{
-			Name: "scambot",
-			Re:   regexp.MustCompile(`(?i)\bscambot\b`),
+			Name:        "scambot",
+			Re:          regexp.MustCompile(`(?i)\bscambot\b`),
+			Description: "Denies being a scambot.",
+			Usage:       "scambot",
 		},
 	}
 }
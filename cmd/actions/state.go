@@ -2,6 +2,11 @@ package actions
 
 import "regexp"
 
+func init() {
+	Register(PriorityHigh, newSleepAction())
+	Register(PriorityHigh, newWakeAction())
+}
+
 type sleepAction struct {
 	options *Options
 }
@@ -9,9 +14,12 @@ type sleepAction struct {
 func newSleepAction() *sleepAction {
 	return &sleepAction{
 		options: &Options{
-			Name: "state.sleep",
-			Re:   regexp.MustCompile(`(?i)^~(shut up|go sleep|sleep|sei ruhig)`),
-			Perm: Moderator,
+			Name:        "state.sleep",
+			Re:          regexp.MustCompile(`(?i)^~(shut up|go sleep|sleep|sei ruhig)`),
+			Perm:        Moderator,
+			Description: "Makes the bot sleep and ignore every message in this channel.",
+			Usage:       "~sleep",
+			Examples:    []string{"~shut up", "~go sleep", "~sei ruhig"},
 		},
 	}
 }
@@ -35,10 +43,13 @@ type wakeAction struct {
 func newWakeAction() *wakeAction {
 	return &wakeAction{
 		options: &Options{
-			Name:      "state.wake",
-			Re:        regexp.MustCompile(`(?i)^~(wake up|wach auf)`),
-			Sleepless: true,
-			Perm:      Moderator,
+			Name:        "state.wake",
+			Re:          regexp.MustCompile(`(?i)^~(wake up|wach auf)`),
+			Sleepless:   true,
+			Perm:        Moderator,
+			Description: "Wakes the bot up. This is the only command that works while the bot sleeps.",
+			Usage:       "~wake up",
+			Examples:    []string{"~wach auf"},
 		},
 	}
 }
@@ -1,8 +1,8 @@
 package actions
 
-import (
-	"regexp"
-)
+func init() {
+	Register(PriorityNormal, newSuicideAction())
+}
 
 type suicideAction struct {
 	options *Options
@@ -11,8 +11,10 @@ type suicideAction struct {
 func newSuicideAction() *suicideAction {
 	return &suicideAction{
 		options: &Options{
-			Name: "suicide",
-			Re:   regexp.MustCompile(`(?i)^~suicide`),
+			Name:        "suicide",
+			Cmd:         &Command{Name: "suicide"},
+			Description: "Times you out for one second.",
+			Examples:    []string{"~suicide"},
 		},
 	}
 }
@@ -22,7 +24,7 @@ func (a suicideAction) GetOptions() *Options {
 }
 
 func (a suicideAction) Run(e *Event) error {
-	e.Say("/timeout " + e.Msg.User.Name + " 1")
+	e.Command("/timeout " + e.Msg.User.Name + " 1")
 
 	return nil
 }
@@ -1,10 +1,10 @@
 package actions
 
-import (
-	"fmt"
-	"regexp"
-	"time"
-)
+import "time"
+
+func init() {
+	Register(PriorityNormal, newTimeAction())
+}
 
 type timeAction struct {
 	options *Options
@@ -13,8 +13,10 @@ type timeAction struct {
 func newTimeAction() *timeAction {
 	return &timeAction{
 		options: &Options{
-			Name: "time",
-			Re:   regexp.MustCompile(`(?i)^~time`),
+			Name:        "time",
+			Cmd:         &Command{Name: "time"},
+			Description: "Shows the time of twitch and of the server.",
+			Examples:    []string{"~time"},
 		},
 	}
 }
@@ -24,8 +26,7 @@ func (a timeAction) GetOptions() *Options {
 }
 
 func (a timeAction) Run(e *Event) error {
-	e.Say(fmt.Sprintf(
-		"Twitch Time: %s Server Time: %s",
+	e.Say(e.T("time.reply",
 		e.Msg.Time.Format(time.RFC3339),
 		time.Now().Format(time.RFC3339),
 	))
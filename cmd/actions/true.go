@@ -1,8 +1,8 @@
 package actions
 
-import (
-	"regexp"
-)
+func init() {
+	Register(PriorityNormal, newTrueAction())
+}
 
 type trueAction struct {
 	options *Options
@@ -11,8 +11,10 @@ type trueAction struct {
 func newTrueAction() *trueAction {
 	return &trueAction{
 		options: &Options{
-			Name: "true",
-			Re:   regexp.MustCompile(`(?i)^~true`),
+			Name:        "true",
+			Cmd:         &Command{Name: "true"},
+			Description: "That's true.",
+			Examples:    []string{"~true"},
 		},
 	}
 }
@@ -2,6 +2,11 @@ package actions
 
 import "regexp"
 
+func init() {
+	Register(PriorityLow, newVanishReplyAction())
+	Register(PriorityLow, newCircumflexAction())
+}
+
 type vanishReplyAction struct {
 	options *Options
 }
@@ -9,9 +14,11 @@ type vanishReplyAction struct {
 func newVanishReplyAction() *vanishReplyAction {
 	return &vanishReplyAction{
 		options: &Options{
-			Name: "vanish-reply",
-			Re:   regexp.MustCompile(`^!vanish`),
-			Perm: Moderator,
+			Name:        "vanish-reply",
+			Re:          regexp.MustCompile(`^!vanish`),
+			Perm:        Moderator,
+			Description: "Reminds mods in moondye7s channel that they can't vanish.",
+			Usage:       "!vanish",
 		},
 	}
 }
@@ -32,7 +39,7 @@ func (a vanishReplyAction) Run(e *Event) error {
 
 	e.Log.Info().Msg("unmod the mods")
 
-	e.Say("Try /unmod " + e.Msg.User.Name + " first weSmart")
+	e.Say(e.T("vanish.reply", e.Msg.User.Name))
 
 	return nil
 }
@@ -47,6 +54,9 @@ func newCircumflexAction() *circumflexAction {
 			Name:             "circumflex",
 			Re:               regexp.MustCompile(`^\^`),
 			DisabledChannels: map[string]bool{"qteeaa": true, "moondye7": true},
+			Description:      "Replies to ^ with ^.",
+			Usage:            "^",
+			Examples:         []string{"^"},
 		},
 	}
 }
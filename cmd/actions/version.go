@@ -1,11 +1,10 @@
 package actions
 
-import (
-	"fmt"
-	"regexp"
+import "github.com/chronophylos/chb3/buildinfo"
 
-	"github.com/chronophylos/chb3/buildinfo"
-)
+func init() {
+	Register(PriorityNormal, newVersionAction())
+}
 
 type versionAction struct {
 	options *Options
@@ -14,8 +13,10 @@ type versionAction struct {
 func newVersionAction() *versionAction {
 	return &versionAction{
 		options: &Options{
-			Name: "version",
-			Re:   regexp.MustCompile(`(?i)^~version`),
+			Name:        "version",
+			Cmd:         &Command{Name: "version"},
+			Description: "Shows the version of the bot.",
+			Examples:    []string{"~version"},
 		},
 	}
 }
@@ -25,8 +26,7 @@ func (a versionAction) GetOptions() *Options {
 }
 
 func (a versionAction) Run(e *Event) error {
-	e.Say(fmt.Sprintf(
-		"I'm a bot written by Chronophylos in Golang. Current version is %s (%s).",
+	e.Say(e.T("version.reply",
 		buildinfo.Version(), buildinfo.Commit(),
 	))
 
@@ -8,6 +8,10 @@ import (
 	"time"
 )
 
+func init() {
+	Register(PriorityNormal, newVoicemailAction())
+}
+
 type voicemailAction struct {
 	options   *Options
 	seperator string
@@ -20,6 +24,9 @@ func newVoicemailAction() *voicemailAction {
 			Name:         "leave voicmail",
 			Re:           regexp.MustCompile(`(?i)^~tell ((\w+)(` + seperator + `(\w+))*) (.*)`),
 			UserCooldown: 30 * time.Second,
+			Description:  "Leaves a message for one or more users which is delivered when they type in chat next time.",
+			Usage:        "~tell <user>[ && <user>…] <message>",
+			Examples:     []string{"~tell marc_yoyo PepegSit", "~tell nightbot && moobot bots FeelsNotsureMan"},
 		},
 		seperator: seperator,
 	}
@@ -46,12 +53,12 @@ func (a voicemailAction) Run(e *Event) error {
 	}
 
 	if len(recipents) == 0 {
-		e.Say("I will not send a message to these recipents")
+		e.Say(e.T("voicemail.no_recipients"))
 		return errors.New("no valid username")
 	}
 
 	if len(message) >= 400 {
-		e.Say("I'm sorry but your message is too long")
+		e.Say(e.T("voicemail.too_long"))
 		return errors.New("message too long")
 	}
 
@@ -77,13 +84,10 @@ func (a voicemailAction) Run(e *Event) error {
 	} else {
 		n := len(recipents) - 1
 		recpientString = strings.Join(recipents[:n], ", ")
-		recpientString += " and " + recipents[n]
+		recpientString += e.T("and") + recipents[n]
 	}
 
-	e.Say(fmt.Sprintf(
-		"I'll forward this message to %s when they type in chat.",
-		recpientString,
-	))
+	e.Say(e.T("voicemail.forward", recpientString))
 
 	return nil
 }
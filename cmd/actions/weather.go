@@ -1,7 +1,6 @@
 package actions
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 	"time"
@@ -9,24 +8,38 @@ import (
 	"github.com/chronophylos/chb3/openweather"
 )
 
+func init() {
+	Register(PriorityNormal, newWeatherAction())
+	Register(PriorityLow, newWeatherQuestionAction())
+}
+
 type weatherAction struct {
 	options *Options
 }
 
-func newWeatherAction1() *weatherAction {
+func newWeatherAction() *weatherAction {
 	return &weatherAction{
 		options: &Options{
 			Name: "weather",
-			Re:   regexp.MustCompile(`(?i)^~(?:weather|wetter) (.*)`),
+			Cmd: &Command{
+				Name:    "weather",
+				Aliases: []string{"wetter"},
+				Args:    []Arg{{Name: "location", Type: ArgRest}},
+			},
+			Description: "Shows the current weather and the forecast for tomorrow.",
+			Examples:    []string{"~weather Berlin"},
 		},
 	}
 }
 
-func newWeatherAction2() *weatherAction {
+func newWeatherQuestionAction() *weatherAction {
 	return &weatherAction{
 		options: &Options{
-			Name: "weather",
-			Re:   regexp.MustCompile(`(?i)^wie ist das wetter in (.*)\?`),
+			Name:        "weather.question",
+			Re:          regexp.MustCompile(`(?i)^wie ist das wetter in (.*)\?`),
+			Description: "Shows the current weather and the forecast for tomorrow.",
+			Usage:       "wie ist das wetter in <place>?",
+			Examples:    []string{"wie ist das wetter in Berlin?"},
 		},
 	}
 }
@@ -36,18 +49,21 @@ func (a weatherAction) GetOptions() *Options {
 }
 
 func (a weatherAction) Run(e *Event) error {
-	where := e.Match[1]
+	where := e.Args.String("location")
+	if where == "" {
+		where = e.Match[1]
+	}
 
 	e.Log.Info().
 		Str("where", where).
 		Msg("Checking the weather")
 
 	if strings.ToLower(where) == "bielefeld" {
-		e.Say("Ich kann Bielefeld nicht finden")
+		e.Say(e.T("location.not_found", "Bielefeld"))
 		return nil
 	}
 
-	err, weatherMessage := getWeather(e.Weather, where)
+	err, weatherMessage := getWeather(e, where)
 	if err != nil {
 		return nil
 	}
@@ -57,13 +73,13 @@ func (a weatherAction) Run(e *Event) error {
 	return nil
 }
 
-const weatherText = "Das aktuelle Wetter für %s, %s: %s bei %.1f°C. Der Wind kommt aus %s mit %.1fm/s bei einer Luftfeuchtigkeit von %d%%. Die Wettervorhersagen für morgen: %s bei %.1f°C."
+func getWeather(e *Event, where string) (error, string) {
+	c := e.Weather
 
-func getWeather(c *openweather.Client, where string) (error, string) {
 	currentWeather, err := c.GetCurrentWeatherByName(where)
 	if err != nil {
 		if err.Error() == "OpenWeather API returned an error with code 404: city not found" {
-			return nil, fmt.Sprintf("Ich kann %s nicht finden", where)
+			return nil, e.T("location.not_found", where)
 		}
 		return err, ""
 	}
@@ -73,7 +89,7 @@ func getWeather(c *openweather.Client, where string) (error, string) {
 		conditions = append(conditions, condition.Description)
 	}
 
-	currentCondition := strings.Join(conditions, " und ")
+	currentCondition := strings.Join(conditions, e.T("and"))
 
 	weatherForecast, err := c.GetWeatherForecastByName(where)
 	if err != nil {
@@ -95,9 +111,9 @@ func getWeather(c *openweather.Client, where string) (error, string) {
 		conditions = append(conditions, condition.Description)
 	}
 
-	tomorrowsConditions := strings.Join(conditions, " und ")
+	tomorrowsConditions := strings.Join(conditions, e.T("and"))
 
-	return nil, fmt.Sprintf(weatherText,
+	return nil, e.T("weather.text",
 		currentWeather.City.Name,
 		currentWeather.City.Country,
 		currentCondition,
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/chronophylos/chb3/cmd/actions"
+	"github.com/chronophylos/chb3/state"
+	"github.com/chronophylos/chb3/web"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type adminAction struct {
+	Name             string
+	Description      string
+	Permission       string
+	Sleepless        bool
+	Enabled          bool
+	DisabledChannels []string
+	Override         *state.ActionState `json:",omitempty"`
+}
+
+// AdminHandler returns the handler of the admin API. It is meant to be
+// mounted at /api/ and serves
+//
+//	GET  /api/channels[?joined=true&lurking=true&sleeping=true]
+//	POST /api/channels/<name>/{join,leave,sleep,wake,lurk,unlurk}
+//	GET  /api/actions
+//	POST /api/actions/<name>/{enable,disable}[?channel=<channel>]
+//	GET  /api/users/<name>
+//	GET  /api/users/<name>/voicemails
+func (m *Manager) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/"), "/")
+
+		log := m.Log.With().
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Logger()
+		log.Debug().Msg("Admin API request")
+
+		switch {
+		case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "channels":
+			m.adminGetChannels(w, r)
+		case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "channels":
+			m.adminChangeChannel(w, strings.ToLower(parts[1]), parts[2])
+		case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "actions":
+			m.adminGetActions(w)
+		case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "actions":
+			m.adminChangeAction(w, parts[1], parts[2], strings.ToLower(r.URL.Query().Get("channel")))
+		case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "users":
+			m.adminGetUser(w, strings.ToLower(parts[1]), false)
+		case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "users" && parts[2] == "voicemails":
+			m.adminGetUser(w, strings.ToLower(parts[1]), true)
+		default:
+			web.WriteError(w, http.StatusNotFound, "not found")
+		}
+	})
+}
+
+func (m *Manager) adminGetChannels(w http.ResponseWriter, r *http.Request) {
+	channels, err := m.State.GetChannels()
+	if err != nil {
+		m.Log.Error().Err(err).Msg("Getting channels")
+		web.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	filtered := []state.Channel{}
+
+	for _, channel := range channels {
+		if query.Get("joined") == "true" && !channel.Joined ||
+			query.Get("lurking") == "true" && !channel.Lurking ||
+			query.Get("sleeping") == "true" && !channel.Sleeping {
+			continue
+		}
+		filtered = append(filtered, channel)
+	}
+
+	web.WriteJSON(w, http.StatusOK, filtered)
+}
+
+func (m *Manager) adminChangeChannel(w http.ResponseWriter, channel, change string) {
+	var err error
+
+	switch change {
+	case "join":
+		m.Twitch.Join(channel)
+		err = m.State.JoinChannel(channel, true)
+	case "leave":
+		if channel == m.BotName {
+			web.WriteError(w, http.StatusBadRequest, "the bot can't leave its own channel")
+			return
+		}
+		m.Twitch.Depart(channel)
+		err = m.State.JoinChannel(channel, false)
+	case "sleep":
+		err = m.State.SetSleeping(channel, true)
+	case "wake":
+		err = m.State.SetSleeping(channel, false)
+	case "lurk":
+		m.Twitch.Join(channel)
+		if err = m.State.SetLurking(channel, true); err == nil {
+			err = m.State.JoinChannel(channel, true)
+		}
+	case "unlurk":
+		err = m.State.SetLurking(channel, false)
+	default:
+		web.WriteError(w, http.StatusNotFound, "unknown change "+change)
+		return
+	}
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		m.Log.Error().
+			Err(err).
+			Str("channel", channel).
+			Str("change", change).
+			Msg("Changing channel")
+		web.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	m.Log.Info().
+		Str("channel", channel).
+		Str("change", change).
+		Msg("Changed channel via admin API")
+
+	c, err := m.State.GetChannel(channel)
+	if err != nil {
+		web.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	web.WriteJSON(w, http.StatusOK, c)
+}
+
+func (m *Manager) adminGetActions(w http.ResponseWriter) {
+	all := []adminAction{}
+
+	for _, action := range m.Actions() {
+		all = append(all, newAdminAction(action.GetOptions()))
+	}
+
+	web.WriteJSON(w, http.StatusOK, all)
+}
+
+func (m *Manager) adminChangeAction(w http.ResponseWriter, name, change, channel string) {
+	var opt *actions.Options
+	for _, action := range m.Actions() {
+		if action.GetOptions().Name == name {
+			opt = action.GetOptions()
+			break
+		}
+	}
+
+	if opt == nil {
+		web.WriteError(w, http.StatusNotFound, "unknown action "+name)
+		return
+	}
+
+	var enabled bool
+	switch change {
+	case "enable":
+		enabled = true
+	case "disable":
+		enabled = false
+	default:
+		web.WriteError(w, http.StatusNotFound, "unknown change "+change)
+		return
+	}
+
+	if err := m.State.SetActionEnabled(name, channel, enabled); err != nil {
+		m.Log.Error().
+			Err(err).
+			Str("action", name).
+			Msg("Changing action")
+		web.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	actions.SetEnabled(name, channel, enabled)
+
+	m.Log.Info().
+		Str("action", name).
+		Str("channel", channel).
+		Bool("enabled", enabled).
+		Msg("Changed action via admin API")
+
+	web.WriteJSON(w, http.StatusOK, newAdminAction(opt))
+}
+
+func (m *Manager) adminGetUser(w http.ResponseWriter, name string, voicemails bool) {
+	user, err := m.State.GetUserByName(name)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			web.WriteError(w, http.StatusNotFound, "unknown user "+name)
+			return
+		}
+		web.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if voicemails {
+		if user.Voicemails == nil {
+			user.Voicemails = []*state.Voicemail{}
+		}
+		web.WriteJSON(w, http.StatusOK, user.Voicemails)
+		return
+	}
+
+	web.WriteJSON(w, http.StatusOK, user)
+}
+
+func newAdminAction(opt *actions.Options) adminAction {
+	a := adminAction{
+		Name:             opt.Name,
+		Description:      opt.Description,
+		Permission:       opt.Perm.String(),
+		Sleepless:        opt.Sleepless,
+		Enabled:          actions.IsEnabled(opt, ""),
+		DisabledChannels: []string{},
+	}
+
+	for channel, disabled := range opt.DisabledChannels {
+		if disabled {
+			a.DisabledChannels = append(a.DisabledChannels, channel)
+		}
+	}
+
+	if s, ok := actions.GetState(opt.Name); ok {
+		a.Override = &s
+	}
+
+	return a
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/chronophylos/chb3/cmd/actions"
+)
+
+// DocFormats are the formats supported by WriteDocs.
+var DocFormats = []string{"adoc", "md"}
+
+type docAction struct {
+	Name        string
+	Usage       string
+	Aliases     []string
+	Description string
+	Examples    []string
+	Sleepless   bool
+	Cooldowns   []string
+	Disabled    bool
+	Channels    []string
+}
+
+type docSection struct {
+	Perm    string
+	Actions []docAction
+}
+
+var docFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+const adocTemplate = `= Chronophylos Bot Version 3
+:icons: font
+:page-permalink: /commands/
+:!page-layout:
+:toc:
+
+// Code generated by "chb3 docs --format adoc"; DO NOT EDIT.
+
+This is a collection of all available commands.
+The default prefix for commands is ` + "`~`" + `. Broadcasters can change it with ` + "`~prefix`" + `.
+{{range .}}
+== {{.Perm}}
+{{range .Actions}}
+=== {{.Name}}
+
+{{with .Description}}{{.}}
+
+{{end}}{{with .Usage}}* Usage: ` + "`{{.}}`" + `
+{{end}}{{with .Aliases}}* Aliases: {{join . ", "}}
+{{end}}* Works while sleeping: {{if .Sleepless}}yes{{else}}no{{end}}
+{{with .Cooldowns}}* Cooldowns: {{join . ", "}}
+{{end}}{{if .Disabled}}* Disabled
+{{end}}{{with .Channels}}* Disabled in: {{join . ", "}}
+{{end}}{{with .Examples}}
+.Examples
+{{range .}} {{.}}
+{{end}}{{end}}{{end}}{{end}}
+// vim: set ft=asciidoctor spell spl=en:
+`
+
+const mdTemplate = `<!-- Code generated by "chb3 docs --format md"; DO NOT EDIT. -->
+
+# Chronophylos Bot Version 3
+
+This is a collection of all available commands.
+The default prefix for commands is ` + "`~`" + `. Broadcasters can change it with ` + "`~prefix`" + `.
+{{range .}}
+## {{.Perm}}
+{{range .Actions}}
+### {{.Name}}
+
+{{with .Description}}{{.}}
+
+{{end}}{{with .Usage}}* Usage: ` + "`{{.}}`" + `
+{{end}}{{with .Aliases}}* Aliases: {{join . ", "}}
+{{end}}* Works while sleeping: {{if .Sleepless}}yes{{else}}no{{end}}
+{{with .Cooldowns}}* Cooldowns: {{join . ", "}}
+{{end}}{{if .Disabled}}* Disabled
+{{end}}{{with .Channels}}* Disabled in: {{join . ", "}}
+{{end}}{{with .Examples}}
+Examples:
+
+` + "```" + `
+{{range .}}{{.}}
+{{end}}` + "```" + `
+{{end}}{{end}}{{end}}`
+
+// WriteDocs writes a reference of all registered actions grouped by their
+// permission level to w. format must be one of DocFormats.
+func WriteDocs(w io.Writer, format string) error {
+	var text string
+
+	switch format {
+	case "adoc":
+		text = adocTemplate
+	case "md":
+		text = mdTemplate
+	default:
+		return fmt.Errorf("unknown format %s", format)
+	}
+
+	tmpl, err := template.New(format).Funcs(docFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("parsing template: %v", err)
+	}
+
+	return tmpl.Execute(w, docSections(actions.GetAll()))
+}
+
+func docSections(all actions.Actions) []docSection {
+	sections := []docSection{}
+
+	for perm := actions.Everyone; perm <= actions.Owner; perm++ {
+		section := docSection{Perm: perm.String()}
+
+		for _, action := range all {
+			opt := action.GetOptions()
+			if opt.Perm != perm {
+				continue
+			}
+
+			section.Actions = append(section.Actions, newDocAction(opt))
+		}
+
+		if len(section.Actions) == 0 {
+			continue
+		}
+
+		sort.SliceStable(section.Actions, func(i, j int) bool {
+			return section.Actions[i].Name < section.Actions[j].Name
+		})
+
+		sections = append(sections, section)
+	}
+
+	return sections
+}
+
+func newDocAction(opt *actions.Options) docAction {
+	a := docAction{
+		Name:        opt.Name,
+		Usage:       actions.UsageFor(opt, actions.DefaultPrefix),
+		Description: opt.Description,
+		Examples:    opt.Examples,
+		Sleepless:   opt.Sleepless,
+		Disabled:    opt.Disabled,
+	}
+
+	if opt.Cmd != nil {
+		for _, alias := range opt.Cmd.Aliases {
+			a.Aliases = append(a.Aliases, "`"+actions.DefaultPrefix+alias+"`")
+		}
+	}
+
+	for _, cooldown := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"user", opt.UserCooldown},
+		{"channel", opt.ChannelCooldown},
+		{"global", opt.GlobalCooldown},
+	} {
+		if cooldown.d > 0 {
+			a.Cooldowns = append(a.Cooldowns, fmt.Sprintf("%s %s", cooldown.d, cooldown.name))
+		}
+	}
+
+	for channel, disabled := range opt.DisabledChannels {
+		if disabled {
+			a.Channels = append(a.Channels, channel)
+		}
+	}
+	sort.Strings(a.Channels)
+
+	return a
+}
@@ -1,41 +1,87 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/chronophylos/chb3/chatlog"
 	"github.com/chronophylos/chb3/cmd/actions"
+	"github.com/chronophylos/chb3/cmd/script"
+	"github.com/chronophylos/chb3/config"
+	"github.com/chronophylos/chb3/history"
+	"github.com/chronophylos/chb3/i18n"
+	"github.com/chronophylos/chb3/markov"
+	"github.com/chronophylos/chb3/metrics"
 	"github.com/chronophylos/chb3/nominatim"
 	"github.com/chronophylos/chb3/openweather"
+	"github.com/chronophylos/chb3/poll"
+	"github.com/chronophylos/chb3/raffle"
+	"github.com/chronophylos/chb3/spam"
 	"github.com/chronophylos/chb3/state"
+	"github.com/chronophylos/chb3/stats"
+	"github.com/chronophylos/chb3/twotsch"
 	"github.com/gempir/go-twitch-irc/v2"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
 type Manager struct {
 	Log           zerolog.Logger
-	Twitch        *twitch.Client
+	Twitch        *twotsch.Client
 	State         *state.Client
 	Location      *nominatim.Client
 	Weather       *openweather.Client
 	ImgurClientID string
 	BotName       string
 
+	// Scripts are run after all registered actions. May be nil.
+	Scripts *script.Engine
+
+	// Stop shuts the bot down. It is called by `~debug exit`. May be nil.
+	Stop func()
+
+	// Spam detects spam in channels with spam moderation.
+	Spam *spam.Detector
+
+	// History are the recent messages of every channel.
+	History *history.History
+
+	// Logs stores messages of channels with logging turned on. Logging is
+	// disabled if it is nil.
+	Logs chatlog.Store
+
+	// Stats counts messages per user and channel.
+	Stats *stats.Counter
+
+	// Markov are the markov chains learned from logged messages.
+	Markov *markov.Model
+
+	// Polls are the running polls of all channels.
+	Polls *poll.Polls
+
+	// Raffles are the running raffles of all channels.
+	Raffles *raffle.Raffles
+
+	// Helix checks raffle rules that need the twitch API. May be nil.
+	Helix raffle.Checker
+	// raffleChecks bounds the number of concurrent checks with Helix.
+	raffleChecks chan struct{}
+
 	actions actions.Actions
 
+	mu      sync.RWMutex
+	closed  bool
+	running sync.WaitGroup
+
 	Config struct {
 		Debug *bool
 	}
 }
 
-func NewManager(twitch *twitch.Client, state *state.Client, weather *openweather.Client, location *nominatim.Client, imgurClientID, botName string, debug *bool) (*Manager, error) {
-	// check actions for errors
-	for _, action := range actions.GetAll() {
-		if err := actions.Check(action); err != nil {
-			return &Manager{}, fmt.Errorf("malformed action %T: %v", action, err)
-		}
-	}
-
+func NewManager(twitch *twotsch.Client, state *state.Client, weather *openweather.Client, location *nominatim.Client, imgurClientID, botName string, debug *bool) (*Manager, error) {
 	m := &Manager{
 		Log:           log.With().Logger(),
 		Twitch:        twitch,
@@ -45,25 +91,184 @@ func NewManager(twitch *twitch.Client, state *state.Client, weather *openweather
 		ImgurClientID: imgurClientID,
 		BotName:       botName,
 		actions:       actions.GetAll(),
+		Spam:          spam.NewDetector(),
+		History:       history.New(history.DefaultSize),
+		Stats:         stats.NewCounter(state),
+		Markov:        markov.NewModel(state),
+		Polls:         poll.New(PollInterval),
+		Raffles:       raffle.New(RaffleTimeout),
+		raffleChecks:  make(chan struct{}, MaxRaffleChecks),
 	}
+	m.Markov.Source = m.markovSource
+	m.Polls.OnUpdate = m.announcePoll
+	m.Polls.OnEnd = m.endPoll
+	m.Raffles.OnConfirm = m.confirmRaffle
+	m.Raffles.OnExpire = m.expireRaffle
+	m.Raffles.OnChange = m.storeRaffle
 	m.Config.Debug = debug
+
+	states, err := state.GetActionStates()
+	if err != nil {
+		return m, fmt.Errorf("getting action states: %v", err)
+	}
+	actions.LoadStates(states)
+
+	ignores, err := state.GetIgnores()
+	if err != nil {
+		return m, fmt.Errorf("getting ignores: %v", err)
+	}
+	actions.LoadIgnores(ignores)
+
+	rules, err := state.GetFilterRules()
+	if err != nil {
+		return m, fmt.Errorf("getting filter rules: %v", err)
+	}
+	actions.LoadFilterRules(rules)
+
+	logged, err := state.GetLoggedChannels()
+	if err != nil {
+		return m, fmt.Errorf("getting logged channels: %v", err)
+	}
+	optOuts, err := state.GetLogOptOuts()
+	if err != nil {
+		return m, fmt.Errorf("getting log opt outs: %v", err)
+	}
+	actions.LoadLogging(logged, optOuts)
+
+	if err := m.Markov.Load(); err != nil {
+		return m, fmt.Errorf("loading markov chains: %v", err)
+	}
+
 	return m, nil
 }
 
-func (m *Manager) RunActions(msg *twitch.PrivateMessage, user *state.User) {
+// Remember adds msg to the message history of its channel.
+func (m *Manager) Remember(msg *twitch.PrivateMessage) {
+	m.History.Add(history.Message{
+		ID:       msg.ID,
+		Channel:  msg.Channel,
+		UserID:   msg.User.ID,
+		UserName: msg.User.Name,
+		Text:     msg.Message,
+		Mod:      isModerator(msg),
+		Time:     msg.Time,
+	})
+}
+
+// Record logs msg if logging is turned on for its channel and the sender did
+// not opt out.
+func (m *Manager) Record(msg *twitch.PrivateMessage) {
+	if m.Logs == nil || !actions.IsLogged(msg.Channel, msg.User.ID) {
+		return
+	}
+
+	if err := m.Logs.AddLogLine(chatlog.FromMessage(msg)); err != nil {
+		m.Log.Error().
+			Err(err).
+			Str("channel", msg.Channel).
+			Msg("Logging message")
+	}
+}
+
+// Learn learns msg for ~markov if it is logged. Only call it for messages
+// that passed the ignore list and moderation so the chains never learn what
+// was removed.
+func (m *Manager) Learn(msg *twitch.PrivateMessage) {
+	if m.Logs == nil || !actions.IsLogged(msg.Channel, msg.User.ID) || !markov.Learnable(msg.Message) {
+		return
+	}
+
+	m.Markov.Learn(msg.Channel, msg.User.ID, msg.Message)
+}
+
+// MarkovRelearnWindow is how far back stale channel chains are learned again
+// from.
+const MarkovRelearnWindow = 7 * 24 * time.Hour
+
+// markovSource returns the logged lines of the last MarkovRelearnWindow in
+// channel that Learn would learn today.
+func (m *Manager) markovSource(channel string) ([]string, error) {
+	if m.Logs == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	lines, err := m.Logs.GetLogLines(channel, "", now.Add(-MarkovRelearnWindow), now)
+	if err != nil {
+		return nil, err
+	}
+
+	var texts []string
+	for _, line := range lines {
+		if !markov.Learnable(line.Text) || actions.IsIgnored(line.UserID, channel) || config.Get().IsIgnored(line.UserID) || actions.IsFiltered(channel, line.Text) {
+			continue
+		}
+		texts = append(texts, line.Text)
+	}
+
+	return texts, nil
+}
+
+// Actions returns all actions in the order they are run.
+func (m *Manager) Actions() actions.Actions {
+	all := make(actions.Actions, 0, len(m.actions))
+	all = append(all, m.actions...)
+	return append(all, m.Scripts.Actions()...)
+}
+
+// Shutdown stops running actions for new messages and waits until all
+// running actions are done or ctx is done.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	m.Polls.Close()
+	m.Raffles.Close()
+
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
+
+// RunActions runs the actions matching msg of user in channel.
+func (m *Manager) RunActions(msg *twitch.PrivateMessage, user *state.User, channel *state.Channel) {
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return
+	}
+	m.running.Add(1)
+	m.mu.RUnlock()
+	defer m.running.Done()
+
+	timer := prometheus.NewTimer(metrics.RunActionsDuration)
+	defer timer.ObserveDuration()
+
 	log := m.Log.With().
 		Str("channel", msg.Channel).
 		Logger()
 
-	sleeping, err := m.State.IsSleeping(msg.Channel)
-	if err != nil {
-		log.Error().
-			Err(err).
-			Msg("Checking if channel is sleeping")
-		return
+	sleeping := channel.Sleeping
+	language := i18n.Pick(user.Language, channel.Language)
+
+	prefix := channel.Prefix
+	if prefix == "" {
+		prefix = actions.DefaultPrefix
 	}
+	name, rest, isCommand := actions.ParseInvocation(prefix, msg.Message)
 
-	for _, action := range m.actions {
+	all := m.Actions()
+	for _, action := range all {
 		opt := action.GetOptions()
 
 		// if sleeping and command is not ignoring sleep
@@ -71,56 +276,86 @@ func (m *Manager) RunActions(msg *twitch.PrivateMessage, user *state.User) {
 			continue
 		}
 
-		var channelDisabled bool
-		if opt.DisabledChannels != nil {
-			_, channelDisabled = opt.DisabledChannels[msg.Channel]
+		if !actions.IsEnabled(opt, msg.Channel) {
+			continue
 		}
 
-		if opt.Disabled || channelDisabled {
+		var match []string
+		var args actions.Args
+		var usageErr error
+
+		if opt.Cmd != nil {
+			if !isCommand || !opt.Cmd.Matches(name) {
+				continue
+			}
+			match = []string{msg.Message, rest}
+			args, usageErr = opt.Cmd.Parse(rest)
+		} else if match = opt.Re.FindStringSubmatch(msg.Message); match == nil {
 			continue
 		}
 
-		if match := opt.Re.FindStringSubmatch(msg.Message); match != nil {
-			log := log.With().
-				Str("action", opt.Name).
-				Str("invoker", msg.User.Name).
-				Logger()
-
-			log.Debug().
-				Strs("match", match).
-				Str("message", msg.Message).
-				Msg("Found matching action")
-
-			e := &actions.Event{
-				Log:           log,
-				Twitch:        m.Twitch,
-				State:         m.State,
-				Weather:       m.Weather,
-				Location:      m.Location,
-				ImgurClientID: m.ImgurClientID,
-				Match:         match,
-				Msg:           msg,
-				Sleeping:      sleeping,
-				BotName:       m.BotName,
-			}
-			e.Init()
-
-			if !e.HasPermission(opt.Perm) {
-				log.Warn().
-					Str("has", e.Perm.String()).
-					Str("needs", opt.Perm.String()).
-					Msg("permission not high enough")
-				continue // Skip
-			}
+		log := log.With().
+			Str("action", opt.Name).
+			Str("invoker", msg.User.Name).
+			Logger()
 
-			if err := action.Run(e); err != nil {
-				log.Error().Err(err).Msg("action failed")
-				return
-			}
+		log.Debug().
+			Strs("match", match).
+			Str("message", msg.Message).
+			Msg("Found matching action")
+		metrics.ActionsMatched.WithLabelValues(opt.Name).Inc()
 
-			if !e.Skipped {
-				return
-			}
+		e := &actions.Event{
+			Log:           log,
+			Twitch:        m.Twitch,
+			State:         m.State,
+			Weather:       m.Weather,
+			Location:      m.Location,
+			ImgurClientID: m.ImgurClientID,
+			Match:         match,
+			Args:          args,
+			Prefix:        prefix,
+			Actions:       all,
+			Msg:           msg,
+			User:          user,
+			Sleeping:      sleeping,
+			Language:      language,
+			BotName:       m.BotName,
+			Stop:          m.Stop,
+			History:       m.History,
+			Logs:          m.Logs,
+			Stats:         m.Stats,
+			Markov:        m.Markov,
+			Polls:         m.Polls,
+			Raffles:       m.Raffles,
+		}
+		e.Init()
+
+		if !e.HasPermission(opt.Perm) {
+			log.Warn().
+				Str("has", e.Perm.String()).
+				Str("needs", opt.Perm.String()).
+				Msg("permission not high enough")
+			continue // Skip
+		}
+
+		if usageErr != nil {
+			log.Info().
+				Err(usageErr).
+				Msg("Wrong usage")
+			e.Say(e.T("usage", actions.UsageFor(opt, prefix)))
+			return
+		}
+
+		metrics.ActionsRun.WithLabelValues(opt.Name).Inc()
+		if err := action.Run(e); err != nil {
+			metrics.ActionsFailed.WithLabelValues(opt.Name).Inc()
+			log.Error().Err(err).Msg("action failed")
+			return
+		}
+
+		if !e.Skipped {
+			return
 		}
 	}
 }
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chronophylos/chb3/cmd/actions"
+	"github.com/chronophylos/chb3/config"
+	"github.com/chronophylos/chb3/filter"
+	"github.com/chronophylos/chb3/i18n"
+	"github.com/chronophylos/chb3/metrics"
+	"github.com/chronophylos/chb3/spam"
+	"github.com/chronophylos/chb3/state"
+	"github.com/gempir/go-twitch-irc/v2"
+)
+
+// Punishments for spam by number of strikes. The first strike is a warning.
+const (
+	StrikeExpire = time.Hour
+	ShortTimeout = time.Minute
+	LongTimeout  = 10 * time.Minute
+)
+
+// Moderate checks msg against the swears of the config and the filter rules
+// of its channel and applies the action of the most severe matching rule.
+// Deleting, timeouts and bans are only applied if the bot is a moderator in
+// the channel. Afterwards msg is checked for spam if the channel turned spam
+// moderation on in channel. It reports wheather msg matched and must not be
+// handled any further.
+func (m *Manager) Moderate(msg *twitch.PrivateMessage, channel *state.Channel) bool {
+	rule, ok := actions.MatchFilter(msg.Channel, msg.Message)
+	if swear, found := config.Get().MatchSwears(msg.Message); found && (!ok || swear.Action > rule.Action) {
+		rule, ok = swear, true
+	}
+	if !ok {
+		return m.moderateSpam(msg, channel)
+	}
+
+	metrics.SwearsFiltered.WithLabelValues(msg.Channel).Inc()
+
+	log := m.Log.With().
+		Str("channel", msg.Channel).
+		Str("user", msg.User.Name).
+		Str("rule", rule.String()).
+		Str("rule-action", rule.Action.String()).
+		Logger()
+
+	if rule.Action == filter.Ignore {
+		log.Info().Msg("Ignoring filtered message")
+		return true
+	}
+
+	// Moderators and broadcasters can't be moderated
+	if isModerator(msg) {
+		log.Info().Msg("Ignoring filtered message of moderator")
+		return true
+	}
+
+	if !m.Twitch.IsModerator(msg.Channel) {
+		log.Info().Msg("Ignoring filtered message because the bot is not a moderator")
+		return true
+	}
+
+	var command string
+	switch rule.Action {
+	case filter.Delete:
+		command = "/delete " + msg.ID
+	case filter.Timeout:
+		d := rule.Duration
+		if d <= 0 {
+			d = filter.DefaultTimeout
+		}
+		command = fmt.Sprintf("/timeout %s %d filtered message", msg.User.Name, int(d.Seconds()))
+	case filter.Ban:
+		command = fmt.Sprintf("/ban %s filtered message", msg.User.Name)
+	}
+
+	log.Info().
+		Str("command", command).
+		Msg("Moderating filtered message")
+	m.Twitch.Command(msg.Channel, command)
+
+	return true
+}
+
+func (m *Manager) moderateSpam(msg *twitch.PrivateMessage, channel *state.Channel) bool {
+	if isModerator(msg) || !m.Twitch.IsModerator(msg.Channel) || !channel.Moderation.Enabled {
+		return false
+	}
+
+	var emotes int
+	for _, emote := range msg.Emotes {
+		emotes += emote.Count
+	}
+
+	check, found := m.Spam.Check(spam.Message{
+		Channel: msg.Channel,
+		UserID:  msg.User.ID,
+		Text:    msg.Message,
+		Emotes:  emotes,
+		Time:    msg.Time,
+	}, func(check string) bool {
+		if check == spam.Links && actions.IsPermitted(msg.Channel, msg.User.Name, msg.Time) {
+			return false
+		}
+		return !channel.Moderation.IsDisabled(check)
+	})
+	if !found {
+		return false
+	}
+
+	log := m.Log.With().
+		Str("channel", msg.Channel).
+		Str("user", msg.User.Name).
+		Str("check", check).
+		Logger()
+
+	strikes, err := m.State.AddStrike(msg.Channel, msg.User.ID, msg.Time, StrikeExpire)
+	if err != nil {
+		log.Error().Err(err).Msg("Adding strike")
+		return true
+	}
+
+	metrics.SpamDetected.WithLabelValues(msg.Channel, check).Inc()
+	log.Info().
+		Int("strikes", strikes).
+		Msg("Detected spam")
+
+	reason := "spam: " + check
+	switch strikes {
+	case 1:
+		m.Twitch.Command(msg.Channel, "/delete "+msg.ID)
+		lang := i18n.Pick(channel.Language)
+		m.Twitch.Say(msg.Channel, i18n.T(lang, "spam.warn", msg.User.DisplayName, i18n.T(lang, "spam."+check)))
+	case 2:
+		m.Twitch.Command(msg.Channel, fmt.Sprintf("/timeout %s %d %s", msg.User.Name, int(ShortTimeout.Seconds()), reason))
+	default:
+		m.Twitch.Command(msg.Channel, fmt.Sprintf("/timeout %s %d %s", msg.User.Name, int(LongTimeout.Seconds()), reason))
+	}
+
+	return true
+}
+
+// isModerator reports wheather the sender of msg is a moderator or the
+// broadcaster. They are never moderated.
+func isModerator(msg *twitch.PrivateMessage) bool {
+	_, ok := msg.User.Badges["moderator"]
+	return ok || msg.User.Name == msg.Channel
+}
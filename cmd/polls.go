@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/chronophylos/chb3/cmd/actions"
+	"github.com/chronophylos/chb3/i18n"
+	"github.com/chronophylos/chb3/poll"
+)
+
+// PollInterval is how often the standings of a poll are announced while
+// votes come in.
+const PollInterval = 30 * time.Second
+
+// language returns the language replies in channel are written in.
+func (m *Manager) language(channel string) string {
+	c, err := m.State.GetChannel(channel)
+	if err != nil {
+		m.Log.Error().
+			Err(err).
+			Str("channel", channel).
+			Msg("Getting channel")
+		return i18n.Pick()
+	}
+	return i18n.Pick(c.Language)
+}
+
+func (m *Manager) announcePoll(r poll.Result) {
+	m.Twitch.Say(r.Channel, actions.PollStandings(m.language(r.Channel), r))
+}
+
+func (m *Manager) endPoll(r poll.Result) {
+	if err := m.State.AddPoll(actions.PollRecord(r, time.Now())); err != nil {
+		m.Log.Error().
+			Err(err).
+			Str("channel", r.Channel).
+			Msg("Storing poll")
+	}
+
+	m.Log.Info().
+		Str("channel", r.Channel).
+		Str("question", r.Question).
+		Ints("counts", r.Counts).
+		Msg("Poll ended")
+
+	m.Twitch.Say(r.Channel, actions.PollResult(m.language(r.Channel), r))
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/chronophylos/chb3/cmd/actions"
+	"github.com/chronophylos/chb3/i18n"
+	"github.com/chronophylos/chb3/raffle"
+	"github.com/gempir/go-twitch-irc/v2"
+)
+
+// RaffleTimeout is how long raffle winners have to write in chat before
+// somebody else is drawn.
+const RaffleTimeout = time.Minute
+
+// MaxRaffleChecks is how many raffle entries are checked with the twitch API
+// at the same time.
+const MaxRaffleChecks = 4
+
+// EnterRaffle enters the sender of msg into the raffle of its channel if msg
+// is the keyword and the sender is eligible. Rules that need the twitch API
+// are checked in the background. Senders who are not eligible can't try
+// again.
+func (m *Manager) EnterRaffle(msg *twitch.PrivateMessage) {
+	rules, ok := m.Raffles.Matches(msg.Channel, msg.User.ID, msg.Message)
+	if !ok {
+		return
+	}
+
+	candidate := raffle.Candidate{
+		UserID:     msg.User.ID,
+		ChannelID:  msg.RoomID,
+		Subscriber: (&actions.Event{Msg: msg}).IsSubscriber(),
+	}
+	entrant := raffle.Entrant{
+		UserID:   msg.User.ID,
+		UserName: msg.User.Name,
+		Time:     msg.Time,
+	}
+
+	if !rules.NeedsTwitch() {
+		m.enterRaffle(msg.Channel, rules, candidate, entrant)
+		return
+	}
+
+	if m.Helix == nil {
+		m.Log.Warn().
+			Str("channel", msg.Channel).
+			Msg("Can't check raffle rules without the twitch API")
+		m.Raffles.Release(msg.Channel, msg.User.ID)
+		return
+	}
+	go func() {
+		m.raffleChecks <- struct{}{}
+		defer func() { <-m.raffleChecks }()
+
+		m.enterRaffle(msg.Channel, rules, candidate, entrant)
+	}()
+}
+
+func (m *Manager) enterRaffle(channel string, rules raffle.Rules, candidate raffle.Candidate, entrant raffle.Entrant) {
+	log := m.Log.With().
+		Str("channel", channel).
+		Str("username", entrant.UserName).
+		Logger()
+
+	eligible, err := rules.Eligible(m.Helix, candidate, entrant.Time)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("Checking raffle rules")
+		m.Raffles.Release(channel, entrant.UserID)
+		return
+	}
+	if !eligible {
+		log.Debug().Msg("Not eligible for raffle")
+		m.Raffles.Reject(channel, entrant.UserID)
+		return
+	}
+
+	if m.Raffles.Enter(channel, entrant) {
+		log.Debug().Msg("Entered raffle")
+	}
+}
+
+func (m *Manager) confirmRaffle(r raffle.Raffle, w raffle.Winner) {
+	m.Log.Info().
+		Str("channel", r.Channel).
+		Str("winner", w.UserName).
+		Msg("Raffle winner confirmed")
+
+	m.Twitch.Say(r.Channel, i18n.T(m.language(r.Channel), "raffle.confirmed", "@"+w.UserName))
+}
+
+func (m *Manager) expireRaffle(r raffle.Raffle, expired raffle.Winner, next *raffle.Winner) {
+	event := m.Log.Info().
+		Str("channel", r.Channel).
+		Str("expired", expired.UserName)
+	if next != nil {
+		event = event.Str("winner", next.UserName)
+	}
+	event.Msg("Raffle winner did not confirm")
+
+	m.Twitch.Say(r.Channel, actions.RaffleExpired(m.language(r.Channel), expired, next, m.Raffles.Timeout))
+}
+
+// storeRaffle stores the audit record of r.
+func (m *Manager) storeRaffle(r raffle.Raffle) {
+	if err := m.State.SetRaffle(actions.RaffleRecord(r)); err != nil {
+		m.Log.Error().
+			Err(err).
+			Str("channel", r.Channel).
+			Msg("Storing raffle")
+	}
+}
@@ -0,0 +1,98 @@
+package script
+
+import (
+	"github.com/chronophylos/chb3/cmd/actions"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// newEvent creates the value passed to the run function of a script. It
+// exposes
+//
+//	event.match         the groups matched by the trigger
+//	event.message       the whole message
+//	event.channel       the channel the message was sent in
+//	event.language      the language replies should use
+//	event.user.id       the twitch id of the sender
+//	event.user.name     the login name of the sender
+//	event.user.display_name
+//	event.user.permission
+//	event.say(text)     sends text to the channel
+//	event.skip()        lets other actions handle the message
+//	event.store.get(key, default=None)
+//	event.store.set(key, value)
+//	event.store.delete(key)
+//
+// The store is shared between all messages but not between scripts.
+func newEvent(name string, e *actions.Event) starlark.Value {
+	match := make(starlark.Tuple, len(e.Match))
+	for i, m := range e.Match {
+		match[i] = starlark.String(m)
+	}
+
+	user := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"id":           starlark.String(e.Msg.User.ID),
+		"name":         starlark.String(e.Msg.User.Name),
+		"display_name": starlark.String(e.Msg.User.DisplayName),
+		"permission":   starlark.String(e.Perm.String()),
+	})
+
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"match":    match,
+		"message":  starlark.String(e.Msg.Message),
+		"channel":  starlark.String(e.Msg.Channel),
+		"language": starlark.String(e.Language),
+		"user":     user,
+		"say": starlark.NewBuiltin("say", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var text string
+			if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &text); err != nil {
+				return nil, err
+			}
+			e.Say(text)
+			return starlark.None, nil
+		}),
+		"skip": starlark.NewBuiltin("skip", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+				return nil, err
+			}
+			e.Skip()
+			return starlark.None, nil
+		}),
+		"store": newStore(name, e),
+	})
+}
+
+func newStore(name string, e *actions.Event) starlark.Value {
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"get": starlark.NewBuiltin("get", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var key string
+			var def starlark.Value = starlark.None
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "key", &key, "default?", &def); err != nil {
+				return nil, err
+			}
+
+			value, ok, err := e.State.GetScriptValue(name, key)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return def, nil
+			}
+			return starlark.String(value), nil
+		}),
+		"set": starlark.NewBuiltin("set", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var key, value string
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "key", &key, "value", &value); err != nil {
+				return nil, err
+			}
+			return starlark.None, e.State.SetScriptValue(name, key, value)
+		}),
+		"delete": starlark.NewBuiltin("delete", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var key string
+			if err := starlark.UnpackArgs(b.Name(), args, kwargs, "key", &key); err != nil {
+				return nil, err
+			}
+			return starlark.None, e.State.DeleteScriptValue(name, key)
+		}),
+	})
+}
@@ -0,0 +1,334 @@
+// Package script loads user-defined actions written in Starlark.
+//
+// A script is a file ending in .star which declares the global variables
+// name, trigger and run. permission, description, usage and examples are
+// optional:
+//
+//	name = "hug"
+//	trigger = r"(?i)^~hug (\w+)"
+//	permission = "everyone"
+//	description = "Hugs someone."
+//
+//	def run(event):
+//	    event.say("%s hugs %s" % (event.user.display_name, event.match[1]))
+//
+// See newEvent for everything a script can access.
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chronophylos/chb3/cmd/actions"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.starlark.net/starlark"
+)
+
+// NamePrefix is prepended to the name of every script action so they can't
+// collide with built-in actions.
+const NamePrefix = "script."
+
+// Engine loads all scripts in a directory and keeps them up to date.
+type Engine struct {
+	Dir string
+
+	// MaxSteps is the maximum number of Starlark computation steps a script
+	// may execute per message.
+	MaxSteps uint64
+	// Timeout is the maximum time a script may run per message.
+	Timeout time.Duration
+
+	log zerolog.Logger
+
+	mu      sync.RWMutex
+	scripts map[string]*scriptAction
+}
+
+// NewEngine creates an Engine for the scripts in dir.
+func NewEngine(dir string, maxSteps uint64, timeout time.Duration) *Engine {
+	return &Engine{
+		Dir:      dir,
+		MaxSteps: maxSteps,
+		Timeout:  timeout,
+		log:      log.With().Str("dir", dir).Logger(),
+		scripts:  map[string]*scriptAction{},
+	}
+}
+
+// Actions returns all loaded scripts sorted by name.
+func (e *Engine) Actions() actions.Actions {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	scripts := make([]*scriptAction, 0, len(e.scripts))
+	for _, s := range e.scripts {
+		scripts = append(scripts, s)
+	}
+	sort.Slice(scripts, func(i, j int) bool {
+		return scripts[i].options.Name < scripts[j].options.Name
+	})
+
+	all := make(actions.Actions, len(scripts))
+	for i, s := range scripts {
+		all[i] = s
+	}
+	return all
+}
+
+// Load (re)loads every script in the directory whose file changed since it
+// was loaded last. Scripts that fail to load are logged and skipped; a script
+// that was loaded before keeps running in its old version.
+func (e *Engine) Load() error {
+	files, err := filepath.Glob(filepath.Join(e.Dir, "*.star"))
+	if err != nil {
+		return err
+	}
+
+	e.mu.RLock()
+	old := e.scripts
+	e.mu.RUnlock()
+
+	scripts := map[string]*scriptAction{}
+	names := map[string]string{}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			e.log.Error().Err(err).Str("file", file).Msg("Reading script")
+			continue
+		}
+
+		s, ok := old[file]
+		if !ok || !s.modTime.Equal(info.ModTime()) {
+			loaded, err := e.load(file, info.ModTime())
+			if err != nil {
+				e.log.Error().Err(err).Str("file", file).Msg("Loading script")
+				if !ok {
+					continue
+				}
+			} else {
+				e.log.Info().
+					Str("file", file).
+					Str("name", loaded.options.Name).
+					Msg("Loaded script")
+				s = loaded
+			}
+		}
+
+		if other, ok := names[s.options.Name]; ok {
+			e.log.Error().
+				Str("file", file).
+				Str("other", other).
+				Str("name", s.options.Name).
+				Msg("Script name is already taken")
+			continue
+		}
+
+		names[s.options.Name] = file
+		scripts[file] = s
+	}
+
+	for file := range old {
+		if _, ok := scripts[file]; !ok {
+			e.log.Info().Str("file", file).Msg("Unloaded script")
+		}
+	}
+
+	e.mu.Lock()
+	e.scripts = scripts
+	e.mu.Unlock()
+
+	return nil
+}
+
+// Watch calls Load every interval until ctx is done.
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Load(); err != nil {
+				e.log.Error().Err(err).Msg("Reloading scripts")
+			}
+		}
+	}
+}
+
+func (e *Engine) load(file string, modTime time.Time) (*scriptAction, error) {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	thread, cancel := e.newThread(file, e.log)
+	defer cancel()
+
+	globals, err := starlark.ExecFile(thread, file, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	globals.Freeze()
+
+	s := &scriptAction{
+		engine:  e,
+		file:    file,
+		modTime: modTime,
+		options: &actions.Options{},
+	}
+
+	name, err := getString(globals, "name", true)
+	if err != nil {
+		return nil, err
+	}
+	s.name = name
+	s.options.Name = NamePrefix + name
+
+	trigger, err := getString(globals, "trigger", true)
+	if err != nil {
+		return nil, err
+	}
+	if s.options.Re, err = regexp.Compile(trigger); err != nil {
+		return nil, fmt.Errorf("compiling trigger: %v", err)
+	}
+
+	if perm, err := getString(globals, "permission", false); err != nil {
+		return nil, err
+	} else if perm != "" {
+		if s.options.Perm, err = actions.ParsePermission(perm); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.options.Description, err = getString(globals, "description", false); err != nil {
+		return nil, err
+	}
+	if s.options.Usage, err = getString(globals, "usage", false); err != nil {
+		return nil, err
+	}
+	if s.options.Examples, err = getStrings(globals, "examples"); err != nil {
+		return nil, err
+	}
+
+	run, ok := globals["run"].(starlark.Callable)
+	if !ok {
+		return nil, errors.New("run must be a function")
+	}
+	s.run = run
+
+	if err := actions.Check(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// newThread creates a thread with the execution budget of e. The returned
+// function must be called once the thread is done.
+func (e *Engine) newThread(name string, log zerolog.Logger) (*starlark.Thread, func()) {
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			log.Info().Str("script", name).Msg(msg)
+		},
+	}
+
+	if e.MaxSteps > 0 {
+		thread.SetMaxExecutionSteps(e.MaxSteps)
+	}
+
+	if e.Timeout <= 0 {
+		return thread, func() {}
+	}
+
+	timer := time.AfterFunc(e.Timeout, func() {
+		thread.Cancel("timeout")
+	})
+	return thread, func() { timer.Stop() }
+}
+
+func getString(globals starlark.StringDict, name string, required bool) (string, error) {
+	v, ok := globals[name]
+	if !ok {
+		if required {
+			return "", fmt.Errorf("%s is required", name)
+		}
+		return "", nil
+	}
+
+	s, ok := starlark.AsString(v)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", name)
+	}
+
+	return strings.TrimSpace(s), nil
+}
+
+func getStrings(globals starlark.StringDict, name string) ([]string, error) {
+	v, ok := globals[name]
+	if !ok {
+		return nil, nil
+	}
+
+	iterable, ok := v.(starlark.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list of strings", name)
+	}
+
+	var strs []string
+	iter := iterable.Iterate()
+	defer iter.Done()
+
+	var x starlark.Value
+	for iter.Next(&x) {
+		s, ok := starlark.AsString(x)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a list of strings", name)
+		}
+		strs = append(strs, s)
+	}
+
+	return strs, nil
+}
+
+type scriptAction struct {
+	engine  *Engine
+	file    string
+	modTime time.Time
+	name    string
+	options *actions.Options
+	run     starlark.Callable
+}
+
+func (a scriptAction) GetOptions() *actions.Options {
+	return a.options
+}
+
+func (a scriptAction) Run(e *actions.Event) error {
+	thread, cancel := a.engine.newThread(a.name, e.Log)
+	defer cancel()
+
+	event := newEvent(a.name, e)
+	if _, err := starlark.Call(thread, a.run, starlark.Tuple{event}, nil); err != nil {
+		return fmt.Errorf("running script %s: %v", a.name, err)
+	}
+
+	return nil
+}
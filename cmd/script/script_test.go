@@ -0,0 +1,66 @@
+package script
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chronophylos/chb3/cmd/actions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeScript(t *testing.T, dir, name, src string) {
+	t.Helper()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644))
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chb3-scripts")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeScript(t, dir, "hug.star", `
+name = "hug"
+trigger = r"(?i)^~hug (\w+)"
+permission = "moderator"
+description = "Hugs someone."
+examples = ["~hug chronophylos"]
+
+def run(event):
+    event.say("hugs " + event.match[1])
+`)
+	writeScript(t, dir, "broken.star", `name = "broken"`)
+	writeScript(t, dir, "endless.star", `
+name = "endless"
+trigger = "endless"
+
+def loop():
+    for i in range(1000000000):
+        pass
+
+loop()
+
+def run(event):
+    pass
+`)
+
+	e := NewEngine(dir, 1000, time.Second)
+	require.NoError(t, e.Load())
+
+	all := e.Actions()
+	require.Len(t, all, 1)
+
+	opt := all[0].GetOptions()
+	assert.Equal(t, "script.hug", opt.Name)
+	assert.Equal(t, actions.Moderator, opt.Perm)
+	assert.Equal(t, "Hugs someone.", opt.Description)
+	assert.Equal(t, []string{"~hug chronophylos"}, opt.Examples)
+	assert.True(t, opt.Re.MatchString("~hug someone"))
+
+	os.Remove(filepath.Join(dir, "hug.star"))
+	require.NoError(t, e.Load())
+	assert.Empty(t, e.Actions())
+}
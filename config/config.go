@@ -0,0 +1,238 @@
+// Package config holds the settings that can change while the bot is running.
+// They are reloaded whenever the config file changes.
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/chronophylos/chb3/filter"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// Action are the defaults of an action set in the config file. They take
+// precedence over the options of the action but not over changes made at
+// runtime.
+type Action struct {
+	Disabled         *bool    `mapstructure:"disabled"`
+	DisabledChannels []string `mapstructure:"disabled_channels"`
+}
+
+// Live are the settings that can change at runtime. A Live must not be
+// modified once it was passed to Set.
+type Live struct {
+	// Swears are words that make the bot ignore a message in all channels.
+	Swears []string
+	// Ignored are the twitch IDs of users the bot ignores.
+	Ignored []string
+	// Owners are the twitch IDs of the owners of the bot.
+	Owners []string
+	// Actions are the defaults of actions by name.
+	Actions map[string]Action
+	// Outbound checks every message the bot sends.
+	Outbound *filter.Outbound
+
+	swears *filter.Filter
+	banned []string
+}
+
+var current atomic.Value
+
+func init() {
+	current.Store(&Live{})
+}
+
+// Get returns the current settings.
+func Get() *Live {
+	return current.Load().(*Live)
+}
+
+// Set replaces the current settings with l.
+func Set(l *Live) {
+	current.Store(l)
+}
+
+// SetDefaults sets the defaults of the live settings on v.
+func SetDefaults(v *viper.Viper) {
+	v.SetDefault("chb3.owners", []string{"54946241"})
+	v.SetDefault("chb3.ignored", []string{"38286541"}) // klotz795
+	v.SetDefault("outbound.max_mentions", 3)
+	v.SetDefault("outbound.allowed_domains", []string{"imgur.com", "wolframalpha.com", "twitch.tv"})
+	v.SetDefault("outbound.censor", true)
+}
+
+// Load reads the live settings from v.
+func Load(v *viper.Viper) (*Live, error) {
+	l := &Live{
+		Swears:  v.GetStringSlice("chb3.swears"),
+		Ignored: v.GetStringSlice("chb3.ignored"),
+		Owners:  v.GetStringSlice("chb3.owners"),
+		Actions: map[string]Action{},
+	}
+
+	if err := v.UnmarshalKey("actions", &l.Actions); err != nil {
+		return nil, fmt.Errorf("reading actions: %v", err)
+	}
+
+	rules := make([]filter.Rule, len(l.Swears))
+	for i, swear := range l.Swears {
+		rules[i] = filter.Rule{Pattern: swear, Action: filter.Ignore}
+	}
+
+	swears, err := filter.New(rules)
+	if err != nil {
+		return nil, fmt.Errorf("reading swears: %v", err)
+	}
+	l.swears = swears
+
+	bannedRules := []filter.Rule{}
+	for _, phrase := range v.GetStringSlice("outbound.banned") {
+		bannedRules = append(bannedRules, filter.Rule{Pattern: phrase, Action: filter.Ignore})
+	}
+	for _, pattern := range v.GetStringSlice("outbound.banned_patterns") {
+		bannedRules = append(bannedRules, filter.Rule{Pattern: pattern, Regex: true, Action: filter.Ignore})
+	}
+	for _, rule := range bannedRules {
+		l.banned = append(l.banned, rule.String())
+	}
+
+	banned, err := filter.New(bannedRules)
+	if err != nil {
+		return nil, fmt.Errorf("reading banned phrases: %v", err)
+	}
+
+	l.Outbound = &filter.Outbound{
+		Banned:         banned,
+		MaxMentions:    v.GetInt("outbound.max_mentions"),
+		AllowedDomains: v.GetStringSlice("outbound.allowed_domains"),
+		Censor:         v.GetBool("outbound.censor"),
+	}
+
+	return l, nil
+}
+
+// Watch reloads the live settings every time the config file of v changes.
+// Invalid configs are logged and ignored.
+func Watch(v *viper.Viper) {
+	v.OnConfigChange(func(e fsnotify.Event) {
+		l, err := Load(v)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("file", e.Name).
+				Msg("Reloading config")
+			return
+		}
+
+		changes := Diff(Get(), l)
+		Set(l)
+
+		log.Info().
+			Str("file", e.Name).
+			Strs("changes", changes).
+			Msg("Reloaded config")
+	})
+	v.WatchConfig()
+}
+
+// IsOwner reports wheather the user with the twitch ID id owns the bot.
+func (l *Live) IsOwner(id string) bool {
+	return contains(l.Owners, id)
+}
+
+// IsIgnored reports wheather the user with the twitch ID id is ignored.
+func (l *Live) IsIgnored(id string) bool {
+	return contains(l.Ignored, id)
+}
+
+// MatchSwears returns the swear found in message.
+func (l *Live) MatchSwears(message string) (filter.Rule, bool) {
+	return l.swears.Match(message)
+}
+
+// Diff describes the changes from old to new, eg. `swears +heck -darn`.
+func Diff(old, new *Live) []string {
+	var changes []string
+
+	changes = appendDiff(changes, "swears", old.Swears, new.Swears)
+	changes = appendDiff(changes, "ignored", old.Ignored, new.Ignored)
+	changes = appendDiff(changes, "owners", old.Owners, new.Owners)
+
+	changes = appendDiff(changes, "outbound.banned", old.banned, new.banned)
+	if o, n := old.Outbound, new.Outbound; o != nil && n != nil {
+		changes = appendDiff(changes, "outbound.allowed_domains", o.AllowedDomains, n.AllowedDomains)
+		if o.MaxMentions != n.MaxMentions {
+			changes = append(changes, fmt.Sprintf("outbound.max_mentions %d -> %d", o.MaxMentions, n.MaxMentions))
+		}
+		if o.Censor != n.Censor {
+			changes = append(changes, fmt.Sprintf("outbound.censor %t -> %t", o.Censor, n.Censor))
+		}
+	}
+
+	names := map[string]bool{}
+	for name := range old.Actions {
+		names[name] = true
+	}
+	for name := range new.Actions {
+		names[name] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		o, n := old.Actions[name], new.Actions[name]
+
+		if fmtBool(o.Disabled) != fmtBool(n.Disabled) {
+			changes = append(changes, fmt.Sprintf("actions.%s.disabled %s -> %s",
+				name, fmtBool(o.Disabled), fmtBool(n.Disabled)))
+		}
+		changes = appendDiff(changes, "actions."+name+".disabled_channels",
+			o.DisabledChannels, n.DisabledChannels)
+	}
+
+	return changes
+}
+
+func appendDiff(changes []string, name string, old, new []string) []string {
+	var diff []string
+
+	for _, s := range new {
+		if !contains(old, s) {
+			diff = append(diff, "+"+s)
+		}
+	}
+	for _, s := range old {
+		if !contains(new, s) {
+			diff = append(diff, "-"+s)
+		}
+	}
+
+	if len(diff) == 0 {
+		return changes
+	}
+	return append(changes, name+" "+strings.Join(diff, " "))
+}
+
+func fmtBool(b *bool) string {
+	if b == nil {
+		return "unset"
+	}
+	return fmt.Sprint(*b)
+}
+
+func contains(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
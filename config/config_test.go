@@ -0,0 +1,66 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+[chb3]
+swears = ["heck"]
+owners = ["1"]
+
+[actions.rate]
+disabled = true
+
+[actions.ping]
+disabled_channels = ["chronophylos"]
+`
+
+func TestLoad(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("toml")
+	SetDefaults(v)
+	assert.NoError(t, v.ReadConfig(strings.NewReader(testConfig)))
+
+	l, err := Load(v)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, l.IsOwner("1"))
+	assert.False(t, l.IsOwner("54946241"))
+	assert.True(t, l.IsIgnored("38286541"))
+
+	_, found := l.MatchSwears("oh h3ck")
+	assert.True(t, found)
+
+	if assert.NotNil(t, l.Actions["rate"].Disabled) {
+		assert.True(t, *l.Actions["rate"].Disabled)
+	}
+	assert.Nil(t, l.Actions["ping"].Disabled)
+	assert.Equal(t, []string{"chronophylos"}, l.Actions["ping"].DisabledChannels)
+}
+
+func TestDiff(t *testing.T) {
+	disabled := true
+
+	old := &Live{
+		Swears: []string{"heck", "darn"},
+		Owners: []string{"1"},
+	}
+	new := &Live{
+		Swears:  []string{"heck", "frick"},
+		Owners:  []string{"1"},
+		Actions: map[string]Action{"rate": {Disabled: &disabled}},
+	}
+
+	assert.Equal(t, []string{
+		"swears +frick -darn",
+		"actions.rate.disabled unset -> true",
+	}, Diff(old, new))
+	assert.Empty(t, Diff(new, new))
+}
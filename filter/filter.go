@@ -0,0 +1,142 @@
+// Package filter matches chat messages against word lists and regular
+// expressions. Messages are normalized first so obfuscated words are found.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Action is what happens to a message matching a rule. Actions are ordered by
+// severity.
+type Action int
+
+// Possible values for Action.
+const (
+	// Ignore makes the bot ignore the message.
+	Ignore Action = iota
+	// Delete deletes the message.
+	Delete
+	// Timeout times the sender out.
+	Timeout
+	// Ban bans the sender.
+	Ban
+)
+
+var actionNames = []string{"ignore", "delete", "timeout", "ban"}
+
+func (a Action) String() string {
+	if int(a) < len(actionNames) {
+		return actionNames[a]
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// ParseAction returns the action named s.
+func ParseAction(s string) (Action, error) {
+	for i, name := range actionNames {
+		if strings.EqualFold(s, name) {
+			return Action(i), nil
+		}
+	}
+	return Ignore, fmt.Errorf("unknown action %s", s)
+}
+
+// DefaultTimeout is used for Timeout rules without a duration.
+const DefaultTimeout = 10 * time.Minute
+
+// Rule is a word or regular expression and the action for matching messages.
+type Rule struct {
+	// Pattern is a word or a regular expression if Regex is set. Words are
+	// normalized and only match whole words of the normalized message.
+	// Regular expressions are matched against the normalized message.
+	Pattern  string
+	Regex    bool
+	Action   Action
+	Duration time.Duration
+}
+
+func (r Rule) String() string {
+	if r.Regex {
+		return "/" + r.Pattern + "/"
+	}
+	return r.Pattern
+}
+
+type compiled struct {
+	Rule
+	re   *regexp.Regexp
+	word string
+	// leet is word with leetspeak replaced. It is empty if the pattern is
+	// mostly digits or symbols.
+	leet string
+}
+
+// Filter matches messages against a list of rules.
+type Filter struct {
+	rules []compiled
+}
+
+// New compiles rules into a filter.
+func New(rules []Rule) (*Filter, error) {
+	f := &Filter{}
+
+	for _, rule := range rules {
+		c := compiled{Rule: rule}
+
+		if rule.Regex {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("compiling %s: %v", rule, err)
+			}
+			c.re = re
+		} else {
+			c.word = words(Fold(rule.Pattern))
+			if c.word == "" {
+				return nil, fmt.Errorf("%q is empty after normalization", rule.Pattern)
+			}
+			if mostlyLetters(rule.Pattern) {
+				c.leet = words(Normalize(rule.Pattern))
+			}
+		}
+
+		f.rules = append(f.rules, c)
+	}
+
+	return f, nil
+}
+
+// Match returns the most severe rule matching message.
+func (f *Filter) Match(message string) (Rule, bool) {
+	if f == nil || len(f.rules) == 0 {
+		return Rule{}, false
+	}
+
+	normalized := Normalize(message)
+	// Leetspeak is matched separately since replacing it turns punctuation
+	// at the end of words into letters
+	plain := words(Fold(message))
+	leet := words(normalized)
+
+	var match Rule
+	var found bool
+
+	for _, rule := range f.rules {
+		var ok bool
+		if rule.re != nil {
+			ok = rule.re.MatchString(normalized)
+		} else {
+			ok = containsWords(plain, rule.word) ||
+				rule.leet != "" && containsWords(leet, rule.leet)
+		}
+
+		if ok && (!found || rule.Action > match.Action) {
+			match = rule.Rule
+			found = true
+		}
+	}
+
+	return match, found
+}
@@ -0,0 +1,99 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Hello", "hello"},
+		{"fûçk", "fuck"},
+		{"h​e\U000e0000ck", "heck"},
+		{"Ｈｅｃｋ", "heck"},
+		{"һеск", "heck"}, // cyrillic
+		{"h3ck", "heck"},
+		{"$h!t", "shit"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			assert.Equal(t, test.want, Normalize(test.text))
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	f, err := New([]Rule{
+		{Pattern: "heck", Action: Delete},
+		{Pattern: `fr[ie]+ck`, Regex: true, Action: Timeout, Duration: time.Hour},
+		{Pattern: "darn", Action: Ignore},
+		{Pattern: "shit", Action: Timeout},
+		{Pattern: "anal", Action: Ban},
+		{Pattern: "tit", Action: Ban},
+		{Pattern: "88", Action: Delete},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		message string
+		want    Action
+		found   bool
+	}{
+		{"hello there", Ignore, false},
+		{"oh h e c k", Delete, true},
+		{"H3CK", Delete, true},
+		{"friiick", Timeout, true},
+		{"darn heck frick", Timeout, true},
+		{"darn", Ignore, true},
+		{"oh heck!", Delete, true},
+		{"h.e.c.k", Delete, true},
+		{"what the $h!t", Timeout, true},
+		{"an alarm went off", Ignore, false},
+		{"what it is", Ignore, false},
+		{"a n a l", Ban, true},
+		{"hecking", Ignore, false},
+		{"88", Delete, true},
+		{"bb", Ignore, false},
+		{"1337 h4x0r", Ignore, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.message, func(t *testing.T) {
+			rule, found := f.Match(test.message)
+			assert.Equal(t, test.found, found)
+			assert.Equal(t, test.want, rule.Action)
+		})
+	}
+
+	_, err = New([]Rule{{Pattern: "(", Regex: true}})
+	assert.Error(t, err)
+}
+
+func TestHasPhrase(t *testing.T) {
+	tests := []struct {
+		text   string
+		phrase string
+		want   bool
+	}{
+		{"Buy Followers at example dot com", "buy followers", true},
+		{"buy   followers!", "buy followers", true},
+		{"what it is", "tit", false},
+		{"i bought 1 thing", "1", true},
+		{"i bought 11 things", "1", false},
+		{"b4d phrase", "bad phrase", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			assert.Equal(t, test.want, HasPhrase(test.text, test.phrase))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// homoglyphs maps characters that look like latin letters to them.
+var homoglyphs = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i',
+	'ј': 'j', 'ѕ': 's', 'һ': 'h', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// leet maps characters used in leetspeak to the letters they replace.
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l',
+}
+
+// Normalize makes text comparable by removing invisible characters and
+// diacritics, lowercasing it and replacing homoglyphs and leetspeak with the
+// latin letters they imitate.
+func Normalize(text string) string {
+	return normalize(text, true)
+}
+
+// Fold is Normalize without replacing leetspeak.
+func Fold(text string) string {
+	return normalize(text, false)
+}
+
+func normalize(text string, replaceLeet bool) string {
+	var b strings.Builder
+
+	for _, r := range norm.NFKD.String(text) {
+		switch {
+		case unicode.IsSpace(r):
+			r = ' '
+		case unicode.Is(unicode.Mn, r), !unicode.IsGraphic(r):
+			// Diacritics, zero-width characters, tags and unassigned
+			// characters like U+E0000
+			continue
+		}
+
+		r = unicode.ToLower(r)
+		if latin, ok := homoglyphs[r]; ok {
+			r = latin
+		}
+		if letter, ok := leet[r]; ok && replaceLeet {
+			r = letter
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// words splits normalized text into words of letters and digits separated by
+// single spaces. Runs of single letters are joined so words split up like
+// "h e c k" or "h.e.c.k" are found.
+func words(text string) string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for i, field := range fields {
+		single := utf8.RuneCountInString(field) == 1
+		joined := single && i > 0 && utf8.RuneCountInString(fields[i-1]) == 1
+		if i > 0 && !joined {
+			b.WriteByte(' ')
+		}
+		b.WriteString(field)
+	}
+	return b.String()
+}
+
+// HasPhrase reports wheather the words of phrase appear in text as whole
+// words after folding both.
+func HasPhrase(text, phrase string) bool {
+	return containsWords(words(Fold(text)), words(Fold(phrase)))
+}
+
+// containsWords reports wheather the words of phrase appear in text as whole
+// words. Both are the output of words.
+func containsWords(text, phrase string) bool {
+	return strings.Contains(" "+text+" ", " "+phrase+" ")
+}
+
+// mostlyLetters reports wheather more than half of the characters in text
+// that are not spaces are letters. Leetspeak is only replaced in patterns
+// like that so patterns like "88" are not read as "bb".
+func mostlyLetters(text string) bool {
+	var letters, others int
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+		case unicode.IsLetter(r):
+			letters++
+		default:
+			others++
+		}
+	}
+	return letters > others
+}
@@ -0,0 +1,114 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrRefused is wrapped by errors of Outbound.Check if a message must not be
+// sent.
+var ErrRefused = errors.New("refused to send message")
+
+var (
+	mentionRe = regexp.MustCompile(`@\w+`)
+	commandRe = regexp.MustCompile(`^[/.][a-z]+(?:\s|$)`)
+	linkRe    = regexp.MustCompile(`(?i)\b(?:https?://)?(?:[a-z0-9-]+\.)+[a-z]{2,}(?:/\S*)?`)
+)
+
+// Outbound checks messages before the bot sends them so users can't make the
+// bot say something it gets banned for.
+type Outbound struct {
+	// Banned are phrases the bot never says. Messages containing them are
+	// always refused.
+	Banned *Filter
+	// MaxMentions is the maximum number of @mentions in a message. 0 means
+	// no limit.
+	MaxMentions int
+	// AllowedDomains are domains the bot may link to including their
+	// subdomains. Other links are not allowed.
+	AllowedDomains []string
+	// Censor makes Check censor mentions, links and commands instead of
+	// refusing the message.
+	Censor bool
+}
+
+// Check returns the message to send instead of text. It returns an error
+// wrapping ErrRefused if the message must not be sent at all.
+func (o *Outbound) Check(text string) (string, error) {
+	// Line breaks would end the IRC command and start a new one
+	text = strings.Join(strings.Fields(text), " ")
+
+	if o == nil {
+		return text, nil
+	}
+
+	if rule, ok := o.Banned.Match(text); ok {
+		return "", fmt.Errorf("%w: contains banned phrase %s", ErrRefused, rule)
+	}
+
+	// Twitch reads messages starting with / or . and a word as commands
+	if commandRe.MatchString(text) {
+		if !o.Censor {
+			return "", fmt.Errorf("%w: starts with a command", ErrRefused)
+		}
+		text = text[1:]
+	}
+
+	if o.MaxMentions > 0 {
+		if mentions := mentionRe.FindAllString(text, -1); len(mentions) > o.MaxMentions {
+			if !o.Censor {
+				return "", fmt.Errorf("%w: %d mentions", ErrRefused, len(mentions))
+			}
+			text = mentionRe.ReplaceAllStringFunc(text, func(m string) string {
+				return m[1:]
+			})
+		}
+	}
+
+	var link string
+	text = linkRe.ReplaceAllStringFunc(text, func(m string) string {
+		if o.allowed(m) {
+			return m
+		}
+		link = m
+		return "<link>"
+	})
+	if link != "" && !o.Censor {
+		return "", fmt.Errorf("%w: contains link %s", ErrRefused, link)
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("%w: empty message", ErrRefused)
+	}
+
+	return text, nil
+}
+
+// Links returns all links in text. Links don't need a scheme, eg.
+// example.com is a link.
+func Links(text string) []string {
+	return linkRe.FindAllString(text, -1)
+}
+
+func (o *Outbound) allowed(link string) bool {
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range o.AllowedDomains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
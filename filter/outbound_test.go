@@ -0,0 +1,60 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboundCheck(t *testing.T) {
+	banned, err := New([]Rule{{Pattern: "bad phrase"}, {Pattern: "tit"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	o := &Outbound{
+		Banned:         banned,
+		MaxMentions:    2,
+		AllowedDomains: []string{"imgur.com"},
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		censor  bool
+		want    string
+		refused bool
+	}{
+		{"plain", "I rate you 10/10", false, "I rate you 10/10", false},
+		{"line break", "hi\r\nPRIVMSG #other :hi", false, "hi PRIVMSG #other :hi", false},
+		{"banned phrase", "this is a b4d phrase", true, "", true},
+		{"command", "/timeout someone", false, "", true},
+		{"command censored", "/timeout someone", true, "timeout someone", false},
+		{"dot command censored", ".ban someone", true, "ban someone", false},
+		{"ellipsis", "...", false, "...", false},
+		{"dot word", ".NET is fine", false, ".NET is fine", false},
+		{"slash number", "/10 would rate again", false, "/10 would rate again", false},
+		{"banned across words", "what it is", false, "what it is", false},
+		{"mentions", "@a @b", false, "@a @b", false},
+		{"mass ping", "@a @b @c", false, "", true},
+		{"mass ping censored", "@a @b @c", true, "a b c", false},
+		{"allowed link", "see https://i.imgur.com/abc.png", false, "see https://i.imgur.com/abc.png", false},
+		{"link", "go to evil.example.com/x", false, "", true},
+		{"link censored", "go to evil.example.com/x", true, "go to <link>", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o.Censor = test.censor
+			got, err := o.Check(test.text)
+
+			if test.refused {
+				assert.True(t, errors.Is(err, ErrRefused), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
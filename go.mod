@@ -1,19 +1,19 @@
 module github.com/chronophylos/chb3
 
-go 1.12
+go 1.16
 
 require (
-	github.com/JoshuaDoes/gofuckyourself v0.0.0-20181118040300-9fac3800924b
 	github.com/Knetic/govaluate v3.0.0+incompatible
 	github.com/akamensky/argparse v1.2.1
 	github.com/armon/consul-api v0.0.0-20180202201655-eb2c6b5be1b6 // indirect
-	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/gempir/go-twitch-irc/v2 v2.4.0
 	github.com/klauspost/compress v1.10.5 // indirect
 	github.com/mitchellh/mapstructure v1.3.0 // indirect
 	github.com/nicklaw5/helix v0.5.9
-	github.com/pelletier/go-toml v1.7.0 // indirect
+	github.com/pelletier/go-toml v1.7.0
 	github.com/pkg/errors v0.9.1 // indirect
+	github.com/prometheus/client_golang v1.11.1
 	github.com/rs/zerolog v1.19.0
 	github.com/spf13/afero v1.2.2 // indirect
 	github.com/spf13/cast v1.3.1 // indirect
@@ -25,9 +25,8 @@ require (
 	github.com/xdg/stringprep v1.0.0 // indirect
 	github.com/xordataexchange/crypt v0.0.3-0.20170626215501-b2862e3d0a77 // indirect
 	go.mongodb.org/mongo-driver v1.3.4
-	golang.org/x/crypto v0.0.0-20200510223506-06a226fb4e37 // indirect
-	golang.org/x/sync v0.0.0-20200317015054-43a5402ce75a // indirect
-	golang.org/x/sys v0.0.0-20200515095857-1151b9dac4a9 // indirect
+	go.starlark.net v0.0.0-20210901212718-87f333178d59
+	golang.org/x/text v0.3.2
 	gopkg.in/ini.v1 v1.56.0 // indirect
 	gopkg.in/yaml.v2 v2.3.0 // indirect
 )
@@ -0,0 +1,160 @@
+// Package health tracks if the bot is still working and reports it over HTTP
+// and to systemd.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chronophylos/chb3/web"
+)
+
+// Pinger checks a dependency like the database.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Checker collects the health of the bot.
+type Checker struct {
+	// MaxSilence is the longest time without receiving anything from twitch
+	// while connected before the bot is considered hung.
+	MaxSilence time.Duration
+	// MaxDisconnected is the longest time the bot may be disconnected before
+	// it is considered hung.
+	MaxDisconnected time.Duration
+
+	db Pinger
+
+	mu           sync.RWMutex
+	connected    bool
+	changed      time.Time
+	lastReceived time.Time
+}
+
+// Status is a snapshot of the health of the bot.
+type Status struct {
+	Connected    bool
+	Since        time.Time
+	LastReceived time.Time
+	Database     string
+}
+
+// NewChecker creates a new Checker. db is pinged on every check.
+func NewChecker(db Pinger, maxSilence, maxDisconnected time.Duration) *Checker {
+	now := time.Now()
+
+	return &Checker{
+		MaxSilence:      maxSilence,
+		MaxDisconnected: maxDisconnected,
+		db:              db,
+		changed:         now,
+		lastReceived:    now,
+	}
+}
+
+// SetConnected records if the bot is connected to twitch.
+func (c *Checker) SetConnected(connected bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.connected == connected {
+		return
+	}
+
+	c.connected = connected
+	c.changed = time.Now()
+	if connected {
+		c.lastReceived = c.changed
+	}
+}
+
+// Received records that something was received from twitch at t. This can be
+// a chat message or a PONG.
+func (c *Checker) Received(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t.After(c.lastReceived) {
+		c.lastReceived = t
+	}
+}
+
+// Status returns the current status. The database is pinged with ctx.
+func (c *Checker) Status(ctx context.Context) Status {
+	c.mu.RLock()
+	s := Status{
+		Connected:    c.connected,
+		Since:        c.changed,
+		LastReceived: c.lastReceived,
+		Database:     "ok",
+	}
+	c.mu.RUnlock()
+
+	if err := c.db.Ping(ctx); err != nil {
+		s.Database = err.Error()
+	}
+
+	return s
+}
+
+// Live reports an error if the bot is hung and should be restarted.
+func (c *Checker) Live(ctx context.Context) error {
+	s := c.Status(ctx)
+
+	if s.Database != "ok" {
+		return fmt.Errorf("database: %s", s.Database)
+	}
+
+	if !s.Connected && time.Since(s.Since) > c.MaxDisconnected {
+		return fmt.Errorf("disconnected since %s", s.Since.Format(time.RFC3339))
+	}
+
+	if s.Connected && time.Since(s.LastReceived) > c.MaxSilence {
+		return fmt.Errorf("nothing received since %s", s.LastReceived.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// Ready reports an error if the bot can't handle messages right now.
+func (c *Checker) Ready(ctx context.Context) error {
+	s := c.Status(ctx)
+
+	if s.Database != "ok" {
+		return fmt.Errorf("database: %s", s.Database)
+	}
+
+	if !s.Connected {
+		return errors.New("not connected to chat")
+	}
+
+	return nil
+}
+
+// LiveHandler serves /healthz.
+func (c *Checker) LiveHandler() http.Handler {
+	return c.handler(c.Live)
+}
+
+// ReadyHandler serves /readyz.
+func (c *Checker) ReadyHandler() http.Handler {
+	return c.handler(c.Ready)
+}
+
+func (c *Checker) handler(check func(context.Context) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		if err := check(ctx); err != nil {
+			web.WriteError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+
+		web.WriteJSON(w, http.StatusOK, c.Status(ctx))
+	})
+}
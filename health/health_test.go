@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (p fakePinger) Ping(ctx context.Context) error {
+	return p.err
+}
+
+func TestChecker(t *testing.T) {
+	tests := []struct {
+		name      string
+		db        error
+		connected bool
+		since     time.Duration
+		received  time.Duration
+		live      bool
+		ready     bool
+	}{
+		{"connected", nil, true, time.Hour, time.Second, true, true},
+		{"starting", nil, false, time.Second, time.Hour, true, false},
+		{"disconnected too long", nil, false, time.Hour, time.Hour, false, false},
+		{"silent too long", nil, true, time.Hour, time.Hour, false, true},
+		{"database down", errors.New("down"), true, time.Hour, time.Second, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewChecker(fakePinger{test.db}, 10*time.Minute, 5*time.Minute)
+			c.connected = test.connected
+			c.changed = time.Now().Add(-test.since)
+			c.lastReceived = time.Now().Add(-test.received)
+
+			assert.Equal(t, test.live, c.Live(context.Background()) == nil)
+			assert.Equal(t, test.ready, c.Ready(context.Background()) == nil)
+		})
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "")
+	assert.Equal(t, time.Duration(0), WatchdogInterval())
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	assert.Equal(t, 30*time.Second, WatchdogInterval())
+
+	t.Setenv("WATCHDOG_PID", "1")
+	assert.Equal(t, time.Duration(0), WatchdogInterval())
+}
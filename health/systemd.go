@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Notify sends state to systemd, eg. "READY=1". It does nothing if the bot
+// was not started by systemd with NotifyAccess set.
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	addr := &net.UnixAddr{Name: socket, Net: "unixgram"}
+	conn, err := net.DialUnix(addr.Net, nil, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// WatchdogInterval returns the watchdog timeout systemd expects or 0 if the
+// watchdog is disabled.
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
+
+// RunWatchdog notifies the systemd watchdog twice per interval as long as the
+// bot is live. It returns once ctx is done or if the watchdog is disabled.
+func (c *Checker) RunWatchdog(ctx context.Context) {
+	interval := WatchdogInterval()
+	if interval == 0 {
+		return
+	}
+
+	log.Info().Dur("interval", interval).Msg("Starting systemd watchdog")
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval/4)
+			err := c.Live(checkCtx)
+			cancel()
+
+			if err != nil {
+				log.Warn().Err(err).Msg("Not notifying watchdog")
+				continue
+			}
+
+			if err := Notify("WATCHDOG=1"); err != nil {
+				log.Error().Err(err).Msg("Notifying watchdog")
+			}
+		}
+	}
+}
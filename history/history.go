@@ -0,0 +1,99 @@
+// Package history remembers the most recent chat messages of every channel.
+package history
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSize is the number of messages remembered per channel if no size is
+// given.
+const DefaultSize = 1000
+
+// Message is a chat message.
+type Message struct {
+	ID       string
+	Channel  string
+	UserID   string
+	UserName string
+	Text     string
+	// Mod is true if the sender is a moderator or the broadcaster.
+	Mod  bool
+	Time time.Time
+}
+
+// History is a ring buffer of messages per channel. It is safe for concurrent
+// use.
+type History struct {
+	size int
+
+	mu       sync.RWMutex
+	channels map[string]*ring
+}
+
+type ring struct {
+	messages []Message
+	next     int
+}
+
+// New creates a History remembering size messages per channel.
+func New(size int) *History {
+	if size <= 0 {
+		size = DefaultSize
+	}
+
+	return &History{
+		size:     size,
+		channels: map[string]*ring{},
+	}
+}
+
+// Add remembers msg. If the buffer of the channel is full the oldest message
+// is forgotten.
+func (h *History) Add(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.channels[msg.Channel]
+	if !ok {
+		r = &ring{messages: make([]Message, 0, h.size)}
+		h.channels[msg.Channel] = r
+	}
+
+	if len(r.messages) < h.size {
+		r.messages = append(r.messages, msg)
+		return
+	}
+
+	r.messages[r.next] = msg
+	r.next = (r.next + 1) % h.size
+}
+
+// Since returns the messages of channel sent at or after t, oldest first.
+func (h *History) Since(channel string, t time.Time) []Message {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	r, ok := h.channels[channel]
+	if !ok {
+		return nil
+	}
+
+	var list []Message
+	for i := range r.messages {
+		msg := r.messages[(r.next+i)%len(r.messages)]
+		if !msg.Time.Before(t) {
+			list = append(list, msg)
+		}
+	}
+
+	return list
+}
+
+// Forget removes all messages of channel, e.g. after leaving it.
+func (h *History) Forget(channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.channels, channel)
+}
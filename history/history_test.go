@@ -0,0 +1,45 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSince(t *testing.T) {
+	now := time.Now()
+	h := New(3)
+
+	for i := 0; i < 5; i++ {
+		h.Add(Message{
+			ID:      string(rune('a' + i)),
+			Channel: "chronophylos",
+			Time:    now.Add(time.Duration(i) * time.Second),
+		})
+	}
+	h.Add(Message{ID: "x", Channel: "someone", Time: now})
+
+	ids := func(list []Message) (ids []string) {
+		for _, msg := range list {
+			ids = append(ids, msg.ID)
+		}
+		return
+	}
+
+	var tests = []struct {
+		channel string
+		since   time.Duration
+		want    []string
+	}{
+		{"chronophylos", 0, []string{"c", "d", "e"}},
+		{"chronophylos", 3 * time.Second, []string{"d", "e"}},
+		{"chronophylos", time.Minute, nil},
+		{"someone", 0, []string{"x"}},
+		{"nobody", 0, nil},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, ids(h.Since(test.channel, now.Add(test.since))), test.channel, test.since)
+	}
+}
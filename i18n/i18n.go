@@ -0,0 +1,157 @@
+// Package i18n provides the message catalogs the bot uses for its replies.
+//
+// Every supported language has a TOML file in the locales directory which is
+// embedded into the binary. Keys are dotted paths into these files, eg.
+// `weather.not_found`.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// DefaultLanguage is used when neither the user nor the channel chose a
+// language or when a key is missing in the chosen language.
+const DefaultLanguage = "de"
+
+//go:embed locales/*.toml
+var locales embed.FS
+
+// Catalog holds all messages for all languages.
+type Catalog struct {
+	messages map[string]map[string]string
+}
+
+var catalog = mustLoad()
+
+// Load parses all embedded locale files into a new Catalog.
+func Load() (*Catalog, error) {
+	c := &Catalog{messages: map[string]map[string]string{}}
+
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		return c, err
+	}
+
+	for _, file := range files {
+		lang := strings.TrimSuffix(file.Name(), path.Ext(file.Name()))
+
+		data, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			return c, fmt.Errorf("reading locale %s: %v", lang, err)
+		}
+
+		tree, err := toml.LoadBytes(data)
+		if err != nil {
+			return c, fmt.Errorf("parsing locale %s: %v", lang, err)
+		}
+
+		messages := map[string]string{}
+		flatten(messages, "", tree.ToMap())
+		c.messages[lang] = messages
+	}
+
+	if _, ok := c.messages[DefaultLanguage]; !ok {
+		return c, fmt.Errorf("default language %s is missing", DefaultLanguage)
+	}
+
+	return c, nil
+}
+
+func mustLoad() *Catalog {
+	c, err := Load()
+	if err != nil {
+		panic("i18n: " + err.Error())
+	}
+	return c
+}
+
+func flatten(messages map[string]string, prefix string, m map[string]interface{}) {
+	for key, value := range m {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(messages, key, v)
+		case string:
+			messages[key] = v
+		}
+	}
+}
+
+// Has reports wheather lang is a supported language.
+func (c *Catalog) Has(lang string) bool {
+	_, ok := c.messages[lang]
+	return ok
+}
+
+// Languages returns all supported languages sorted by their code.
+func (c *Catalog) Languages() []string {
+	langs := make([]string, 0, len(c.messages))
+	for lang := range c.messages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// T looks up key in lang and formats it with args. If lang does not have key
+// the default language is used. If that is missing too the key itself is
+// returned so missing messages are easy to spot in chat.
+func (c *Catalog) T(lang, key string, args ...interface{}) string {
+	format, ok := c.messages[lang][key]
+	if !ok {
+		format, ok = c.messages[DefaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Plural looks up key.one if n is 1 and key.other otherwise. For key.other n
+// is passed as the first argument followed by args, key.one only gets args.
+func (c *Catalog) Plural(lang, key string, n int, args ...interface{}) string {
+	if n == 1 {
+		return c.T(lang, key+".one", args...)
+	}
+	return c.T(lang, key+".other", append([]interface{}{n}, args...)...)
+}
+
+// Pick returns the first supported language of langs or DefaultLanguage.
+func (c *Catalog) Pick(langs ...string) string {
+	for _, lang := range langs {
+		if c.Has(lang) {
+			return lang
+		}
+	}
+	return DefaultLanguage
+}
+
+// Has reports wheather lang is supported by the embedded catalog.
+func Has(lang string) bool { return catalog.Has(lang) }
+
+// Languages returns all languages of the embedded catalog.
+func Languages() []string { return catalog.Languages() }
+
+// T looks up key in the embedded catalog. See Catalog.T.
+func T(lang, key string, args ...interface{}) string { return catalog.T(lang, key, args...) }
+
+// Plural looks up a pluralized key in the embedded catalog. See Catalog.Plural.
+func Plural(lang, key string, n int, args ...interface{}) string {
+	return catalog.Plural(lang, key, n, args...)
+}
+
+// Pick returns the first language supported by the embedded catalog.
+func Pick(langs ...string) string { return catalog.Pick(langs...) }
@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogKeysExistInDefaultLanguage(t *testing.T) {
+	for lang, messages := range catalog.messages {
+		for key := range messages {
+			if _, ok := catalog.messages[DefaultLanguage][key]; !ok {
+				t.Errorf("key %s of language %s is missing in %s", key, lang, DefaultLanguage)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	c := &Catalog{messages: map[string]map[string]string{
+		"en": {"greet": "Hello %s"},
+		"de": {"greet": "Hallo %s", "only.de": "Deutsch"},
+	}}
+
+	tests := []struct {
+		name string
+		lang string
+		key  string
+		args []interface{}
+		want string
+	}{
+		{"default language", "de", "greet", []interface{}{"Bob"}, "Hallo Bob"},
+		{"other language", "en", "greet", []interface{}{"Bob"}, "Hello Bob"},
+		{"fallback to default", "en", "only.de", nil, "Deutsch"},
+		{"unknown language", "fr", "greet", []interface{}{"Bob"}, "Hallo Bob"},
+		{"missing key", "en", "missing", nil, "missing"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, c.T(test.lang, test.key, test.args...))
+		})
+	}
+}
+
+func TestPlural(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("one day", Plural("en", "duration.day", 1))
+	assert.Equal("3 days", Plural("en", "duration.day", 3))
+	assert.Equal("ein Tag", Plural("de", "duration.day", 1))
+	assert.Equal("@bob, 2 messages for you: ", Plural("en", "voicemail.replay", 2, "bob"))
+}
+
+func TestPick(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("de", Pick("", "de"))
+	assert.Equal("de", Pick("de", "en"))
+	assert.Equal(DefaultLanguage, Pick("xx", ""))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronophylos/chb3/chatlog"
+	"github.com/chronophylos/chb3/cmd/actions"
+	"github.com/chronophylos/chb3/state"
+	"github.com/rs/zerolog/log"
+)
+
+// importLogs imports the justlog archive in dir into the configured chat log
+// store.
+func importLogs(dir string) {
+	readConfig()
+
+	var err error
+	stateClient, err = state.NewClient("mongodb://localhost:27017")
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Could not create State Client")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		stateClient.Disconnect(ctx)
+	}()
+
+	store, files := newLogStore()
+	if store == nil {
+		log.Fatal().Msg("Chat logs are disabled. Set logs.store first.")
+	}
+	if files != nil {
+		defer files.Close()
+	}
+
+	// Messages of channels without logging and users who opted out must not
+	// come back with an import
+	logged, err := stateClient.GetLoggedChannels()
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Could not get logged channels")
+	}
+	optOuts, err := stateClient.GetLogOptOuts()
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Could not get log opt outs")
+	}
+	actions.LoadLogging(logged, optOuts)
+
+	n, err := chatlog.Import(store, dir, actions.IsLogged)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Int("messages", n).
+			Msg("Could not import logs")
+	}
+
+	log.Info().
+		Str("dir", dir).
+		Int("messages", n).
+		Msg("Imported logs")
+}
@@ -1,21 +1,36 @@
 package main
 
+//go:generate go run . docs --format adoc --output docs/commands.adoc
+//go:generate go run . docs --format md --output docs/commands.md
+
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
-	sw "github.com/JoshuaDoes/gofuckyourself"
 	"github.com/akamensky/argparse"
 	"github.com/chronophylos/chb3/buildinfo"
+	"github.com/chronophylos/chb3/chatlog"
 	"github.com/chronophylos/chb3/cmd"
+	"github.com/chronophylos/chb3/cmd/actions"
+	"github.com/chronophylos/chb3/cmd/script"
+	"github.com/chronophylos/chb3/config"
+	"github.com/chronophylos/chb3/health"
+	"github.com/chronophylos/chb3/i18n"
+	"github.com/chronophylos/chb3/metrics"
 	"github.com/chronophylos/chb3/nominatim"
 	"github.com/chronophylos/chb3/openweather"
+	"github.com/chronophylos/chb3/raffle"
 	"github.com/chronophylos/chb3/state"
+	"github.com/chronophylos/chb3/token"
+	"github.com/chronophylos/chb3/twotsch"
+	"github.com/chronophylos/chb3/web"
 	"github.com/gempir/go-twitch-irc/v2"
 	"github.com/nicklaw5/helix"
 	"github.com/rs/zerolog"
@@ -36,23 +51,14 @@ var (
 	imgurClientID string
 
 	openweatherAppID string
-
-	swears []string
 )
 
-var userBlacklist = []string{
-	//"86621952", // ritzenspalt
-	"38286541", // klotz795
-}
-
 // Globals
 var (
 	owClient     *openweather.Client
 	stateClient  *state.Client
-	twitchClient *twitch.Client
-	swearfilter  *sw.SwearFilter
+	twitchClient *twotsch.Client
 	osmClient    *nominatim.Client
-	helixClient  *helix.Client
 )
 
 func main() {
@@ -66,8 +72,30 @@ func main() {
 	showSecrets = parser.Flag("", "show-secrets",
 		&argparse.Options{Help: "Show secrets in log (eg. your twitch token)."})
 
+	parser.NewCommand("run", "Run the bot. This is the default command.")
+
+	docsCmd := parser.NewCommand("docs", "Generate the command reference from the registered actions.")
+	docsFormat := docsCmd.Selector("f", "format", cmd.DocFormats,
+		&argparse.Options{Default: "adoc", Help: "Format of the reference."})
+	docsOutput := docsCmd.String("o", "output",
+		&argparse.Options{Default: "-", Help: "File to write the reference to. - is stdout."})
+
+	importCmd := parser.NewCommand("import", "Import a justlog archive into the chat logs.")
+	importDir := importCmd.String("d", "dir",
+		&argparse.Options{Required: true, Help: "The logs directory of justlog."})
+
+	authCmd := parser.NewCommand("auth", "Authorize the bot with twitch and store the token.")
+	authCode := authCmd.String("c", "code",
+		&argparse.Options{Help: "Authorization code. Asked for if not set."})
+
+	// Default to the run command so `chb3 --debug` keeps working.
+	args := os.Args
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		args = append([]string{args[0], "run"}, args[1:]...)
+	}
+
 	// Parse Flags
-	err := parser.Parse(os.Args)
+	err := parser.Parse(args)
 	if err != nil {
 		// Print usage for err
 		fmt.Print(parser.Usage(err))
@@ -78,28 +106,20 @@ func main() {
 	// Setup logger
 	setGlobalLogger()
 
-	// Viper {{{
-	viper.SetConfigType("toml") // toml is nice
-	viper.SetConfigName("config")
-	viper.AddConfigPath("/etc/chb3") // config location
-	viper.AddConfigPath(".")         // also look in the working directory
-
-	// Not sure what to use this for yet.
-	viper.SetEnvPrefix("CHB3")
-
-	err = viper.ReadInConfig()
-	if err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			// Config file not found
-			log.Fatal().
-				Err(err).
-				Msg("Error config not found.")
-		}
-		log.Fatal().
-			Err(err).
-			Msg("Error reading config.")
+	switch {
+	case docsCmd.Happened():
+		generateDocs(*docsFormat, *docsOutput)
+	case authCmd.Happened():
+		authorize(*authCode)
+	case importCmd.Happened():
+		importLogs(*importDir)
+	default:
+		run()
 	}
-	// }}}
+}
+
+func run() {
+	readConfig()
 
 	// Required Settings {{{
 	if !viper.IsSet("twitch.username") {
@@ -107,10 +127,6 @@ func main() {
 	}
 	twitchUsername = viper.GetString("twitch.username")
 
-	if !viper.IsSet("twitch.token") {
-		log.Fatal().Msg("Twitch Token is not set.")
-	}
-
 	if !viper.IsSet("twitch.clientid") {
 		log.Fatal().Msg("Twitch ClientID is not set.")
 	}
@@ -125,30 +141,34 @@ func main() {
 	}
 	openweatherAppID = viper.GetString("openweather.appid")
 
-	swears = viper.GetStringSlice("chb3.swears")
 	// }}}
 
+	live, err := config.Load(viper.GetViper())
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Error reading config.")
+	}
+	config.Set(live)
+	config.Watch(viper.GetViper())
+	log.Info().
+		Strs("swears", live.Swears).
+		Strs("ignored", live.Ignored).
+		Strs("owners", live.Owners).
+		Msg("Loaded live config")
+
 	log.Info().Msgf("Starting CHB3 %s (%s)", buildinfo.Version(), buildinfo.Commit())
 
 	// Signals {{{
-	// setup signal catching
-	sigs := make(chan os.Signal, 1)
-
-	// catch all term signals
-	signal.Notify(sigs, os.Interrupt)
-
-	// method invoked upon seeing signal
-	go func() {
-		s := <-sigs
-		log.Info().Msgf("Received %s. Quitting.", s)
-		twitchClient.Disconnect()
-		os.Exit(1)
-	}()
+	// ctx is cancelled on SIGINT, SIGTERM or `~debug exit`. A second signal
+	// kills the bot immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	// }}}
 
 	wg := sync.WaitGroup{}
 
-	wg.Add(5)
+	wg.Add(4)
 
 	go func() {
 		stateClient, err = state.NewClient("mongodb://localhost:27017")
@@ -163,6 +183,7 @@ func main() {
 
 	go func() {
 		owClient = openweather.NewClient(openweatherAppID, buildinfo.Version())
+		owClient.SetTransport(metrics.Transport("openweather", nil))
 		wg.Done()
 		log.Info().
 			Str("appid", censor(openweatherAppID)).
@@ -170,71 +191,208 @@ func main() {
 	}()
 
 	go func() {
-		token := viper.GetString("twitch.token")
-		token = "oauth:" + token
-		twitchClient = twitch.NewClient(twitchUsername, token)
+		oauth := "oauth:" + viper.GetString("twitch.token")
+		twitchClient = twotsch.NewClient(twitchUsername, oauth)
 		wg.Done()
 		log.Info().
 			Str("username", twitchUsername).
-			Str("token", censor(token)).
 			Msg("Created new Twitch Client")
 	}()
 
-	// why did i make this concurrent?
 	go func() {
-		swearfilter = &sw.SwearFilter{BlacklistedWords: swears}
-		wg.Done()
-		log.Info().Strs("swears", swears).Msg("Loaded Swearfilter")
-	}()
-
-	go func() {
-		osmClient = &nominatim.Client{UserAgent: "ChronophylosBot/" + buildinfo.Version()}
+		osmClient = &nominatim.Client{
+			UserAgent: "ChronophylosBot/" + buildinfo.Version(),
+			Transport: metrics.Transport("nominatim", nil),
+		}
 		wg.Done()
 		log.Info().Msg("Created OpenStreetMaps Client")
 	}()
 
 	wg.Wait()
 
-	helixClient, err = helix.NewClient(&helix.Options{
-		ClientID:     viper.GetString("twitch.clientid"),
-		ClientSecret: viper.GetString("twitch.secret"),
-		UserAgent:    "ChronophylosBot/" + buildinfo.Version(),
-		RedirectURI:  "https://localhost",
-		Scopes:       []string{"chat:read", "chat:edit", "channel:moderate", "moderation:read", "channel_editor"},
-	})
+	helixClient, err := newHelixClient()
 	if err != nil {
 		log.Fatal().
 			Err(err).
 			Msg("Could not create helix client")
 	}
 
+	// Tokens {{{
+	tokens := token.NewSource(twitchUsername, helixClient, stateClient)
+	err = tokens.Load()
+	useTokens := err == nil
+
+	switch {
+	case useTokens:
+		log.Info().
+			Str("token", censor(tokens.AccessToken())).
+			Time("expires", tokens.ExpiresAt()).
+			Msg("Loaded twitch token")
+		twitchClient.SetTokenSource(tokens.IRCToken)
+		go tokens.Run(ctx)
+	case errors.Is(err, token.ErrNoToken) && viper.IsSet("twitch.token"):
+		log.Warn().
+			Str("token", censor(viper.GetString("twitch.token"))).
+			Msg("Using static twitch.token. Run `chb3 auth` to refresh the token automatically.")
+	default:
+		log.Fatal().
+			Err(err).
+			Msg("Could not load twitch token")
+	}
+	// }}}
+
+	if err := stateClient.EnsureStatsIndexes(); err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Could not create stats indexes")
+	}
+	if err := stateClient.EnsureQuoteIndexes(); err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Could not create quote indexes")
+	}
+
 	manager, err := cmd.NewManager(twitchClient, stateClient, owClient, osmClient, imgurClientID, twitchUsername, debug)
 	if err != nil {
 		log.Fatal().
 			Err(err).
 			Msg("could not create command manager")
 	}
+	manager.Stop = stop
+	manager.Raffles.Timeout = viper.GetDuration("raffle.confirm")
+
+	go manager.Stats.Run(ctx, viper.GetDuration("stats.flush"))
+	go manager.Markov.Run(ctx, viper.GetDuration("markov.save"))
+
+	helixToken := tokens.AccessToken
+	if !useTokens {
+		helixToken = func() string { return viper.GetString("twitch.token") }
+	}
+	helixChecker := raffle.NewHelix(viper.GetString("twitch.clientid"), helixToken)
+	helixChecker.Transport = metrics.Transport("helix", nil)
+	manager.Helix = helixChecker
+
+	// Chat Logs {{{
+	logStore, logFiles := newLogStore()
+	if logStore != nil {
+		manager.Logs = logStore
+	} else {
+		log.Info().Msg("Chat logs are disabled")
+	}
+	if logFiles != nil {
+		go logFiles.Run(ctx)
+	}
+	// }}}
+
+	twitchClient.SetOutboundFilter(func(text string) (string, error) {
+		return config.Get().Outbound.Check(text)
+	})
+
+	checker := health.NewChecker(stateClient,
+		viper.GetDuration("health.silence"),
+		viper.GetDuration("health.disconnected"),
+	)
+	go checker.RunWatchdog(ctx)
+
+	// Scripts {{{
+	scriptsDir := viper.GetString("scripts.dir")
+	if _, err := os.Stat(scriptsDir); err == nil {
+		engine := script.NewEngine(scriptsDir,
+			uint64(viper.GetInt64("scripts.steps")),
+			viper.GetDuration("scripts.timeout"),
+		)
+		if err := engine.Load(); err != nil {
+			log.Error().
+				Err(err).
+				Str("dir", scriptsDir).
+				Msg("Could not load scripts")
+		}
+		go engine.Watch(ctx, viper.GetDuration("scripts.reload"))
+
+		manager.Scripts = engine
+	} else {
+		log.Info().
+			Str("dir", scriptsDir).
+			Msg("Scripts are disabled")
+	}
+	// }}}
+
+	// HTTP Server {{{
+	var server *web.Server
+	if viper.GetBool("http.enabled") {
+		token := viper.GetString("http.token")
+		if token == "" {
+			log.Fatal().Msg("HTTP server is enabled but http.token is not set.")
+		}
+
+		server = web.NewServer(viper.GetString("http.listen"), token)
+		server.HandleAuth("/api/", manager.AdminHandler())
+		server.Handle("/metrics", metrics.Handler())
+		server.Handle("/healthz", checker.LiveHandler())
+		server.Handle("/readyz", checker.ReadyHandler())
+
+		if logStore != nil {
+			api := chatlog.NewAPI(logStore, stateClient)
+			api.Logged = actions.IsChannelLogged
+			if viper.GetBool("logs.public") {
+				server.Handle("/channel/", api)
+				server.Handle("/list", api)
+			} else {
+				server.HandleAuth("/channel/", api)
+				server.HandleAuth("/list", api)
+			}
+		}
+
+		go func() {
+			if err := server.ListenAndServe(); err != nil {
+				log.Fatal().
+					Err(err).
+					Msg("HTTP server failed")
+			}
+		}()
+	}
+	// }}}
 
 	// Twitch Client Event Handling {{{
 	twitchClient.OnReconnectMessage(func(message twitch.ReconnectMessage) {
 		log.Info().Msg("Reconnected to chat")
 	})
 
+	twitchClient.OnPingMessage(func(message twitch.PingMessage) {
+		checker.Received(time.Now())
+	})
+
+	twitchClient.OnPongMessage(func(message twitch.PongMessage) {
+		checker.Received(time.Now())
+	})
+
 	twitchClient.OnPrivateMessage(func(message twitch.PrivateMessage) {
+		checker.Received(message.Time)
+
+		// Don't handle messages while shutting down
+		if ctx.Err() != nil {
+			return
+		}
+
 		// Don't listen to messages sent by the bot
 		if message.User.Name == twitchUsername {
 			return
 		}
 
-		for _, userID := range userBlacklist {
-			if message.User.ID == userID {
-				return
-			}
-		}
+		metrics.MessagesReceived.WithLabelValues(message.Channel).Inc()
 
 		message.Message = strings.ReplaceAll(message.Message, "\U000e0000", "")
 		message.Message = strings.TrimSpace(message.Message)
 
+		// Remember messages of ignored users too so they can be nuked
+		manager.Remember(&message)
+		manager.Record(&message)
+
+		live := config.Get()
+		if live.IsIgnored(message.User.ID) || actions.IsIgnored(message.User.ID, message.Channel) {
+			return
+		}
+
 		user, err := stateClient.BumpUser(message.User, message.Time)
 		if err != nil {
 			log.Error().
@@ -244,40 +402,49 @@ func main() {
 			return
 		}
 
-		isLurking, err := stateClient.IsLurking(message.Channel)
+		manager.Stats.Add(message.Channel, message.User.ID, message.User.Name, message.Time)
+
+		// The channel is fetched once for moderation and the actions
+		channel, err := stateClient.GetChannel(message.Channel)
 		if err != nil {
 			log.Error().
 				Err(err).
 				Str("channel", message.Channel).
-				Msg("Checking if bot is lurking")
+				Msg("Getting channel")
 			return
 		}
 
-		if isLurking {
+		if channel.Lurking {
 			return
 		}
 
-		foundASwear, swearsFound, err := swearfilter.Check(message.Message)
-		if err != nil {
-			log.Error().
-				Str("message", message.Message).
-				Msg("Checking message for swears")
-			return
-		}
-		if foundASwear {
-			log.Info().
-				Strs("swears", swearsFound).
-				Msg("Found forbidden words")
+		if manager.Moderate(&message, &channel) {
 			return
 		}
 
-		manager.RunActions(&message, user)
+		manager.Learn(&message)
+		manager.Polls.Vote(message.Channel, message.User.ID, message.Message)
+		manager.Raffles.Confirm(message.Channel, message.User.ID)
+		manager.EnterRaffle(&message)
+
+		manager.RunActions(&message, user, &channel)
+
+		checkForVoicemails(user, message.Channel)
+	})
 
-		checkForVoicemails(message.User.Name, message.Channel)
+	twitchClient.OnDisconnect(func(err error) {
+		checker.SetConnected(false)
 	})
 
+	var notifyReady sync.Once
 	twitchClient.OnConnect(func() {
 		log.Info().Msg("Connected to chat")
+		checker.SetConnected(true)
+		notifyReady.Do(func() {
+			if err := health.Notify("READY=1"); err != nil {
+				log.Error().Err(err).Msg("Notifying systemd")
+			}
+		})
 		twitchClient.Say(twitchUsername,
 			fmt.Sprintf("CHB3 %s (%s) has started FeelsGoodMan",
 				buildinfo.Version(), buildinfo.Commit(),
@@ -300,29 +467,206 @@ func main() {
 	stateClient.JoinChannel(twitchUsername, true)
 	twitchClient.Join(joinedChannels...)
 
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		<-ctx.Done()
+		stop()
+
+		log.Info().Msg("Shutting down")
+		if err := health.Notify("STOPPING=1"); err != nil {
+			log.Error().Err(err).Msg("Notifying systemd")
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("shutdown.timeout"))
+		defer cancel()
+
+		if err := manager.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Waiting for running actions")
+		}
+		if err := manager.Stats.Flush(); err != nil {
+			log.Error().Err(err).Msg("Flushing stats")
+		}
+		if err := manager.Markov.Save(); err != nil {
+			log.Error().Err(err).Msg("Saving markov chains")
+		}
+		if err := twitchClient.Flush(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Flushing outgoing messages")
+		}
+		twitchClient.Disconnect()
+
+		if logFiles != nil {
+			if err := logFiles.Close(); err != nil {
+				log.Error().Err(err).Msg("Closing chat logs")
+			}
+		}
+	}()
+
+	backoff := &twotsch.Backoff{
+		Min:    viper.GetDuration("reconnect.min"),
+		Max:    viper.GetDuration("reconnect.max"),
+		Factor: 2,
+		Jitter: 0.2,
+	}
+	rejoin := func() ([]string, error) {
+		channels, err := stateClient.GetJoinedChannels()
+		return append(channels, twitchUsername), err
+	}
+
+	var lastRefresh time.Time
 	for {
-		var hasConnected bool
-		log.Info().Msg("Connecting to chat")
+		err = twitchClient.Run(ctx, backoff, rejoin)
+		if !errors.Is(err, twitch.ErrLoginAuthenticationFailed) {
+			break
+		}
 
-		if twitchClient.Connect(); err != nil {
-			hasConnected = true
+		// Refresh the token once in case it expired. If the login fails again
+		// right away the token is useless.
+		if !useTokens || time.Since(lastRefresh) < time.Minute {
 			log.Fatal().
 				Err(err).
-				Msg("Failed to connect to chat")
+				Msg("Could not connect to chat. Run `chb3 auth` to get a new token.")
 		}
-		if !hasConnected {
-			log.Fatal().Msg("Could not connect to chat. Try getting a new token: " + helixClient.GetAuthorizationURL("", false))
+		if err := tokens.Refresh(); err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Could not refresh token. Run `chb3 auth` to get a new token.")
 		}
-		hasConnected = false
+		lastRefresh = time.Now()
+	}
+
+	<-stopped
+	shutdown(server)
+}
 
-		log.Info().Msg("Disconnected from chat")
+// shutdown stops the HTTP server and disconnects from the database.
+func shutdown(server *web.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("shutdown.timeout"))
+	defer cancel()
 
-		time.Sleep(10 * time.Second)
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
+			log.Error().Err(err).Msg("Stopping HTTP server")
+		}
+	}
+
+	if err := stateClient.Disconnect(ctx); err != nil {
+		log.Error().Err(err).Msg("Disconnecting from database")
+	}
+
+	log.Info().Msg("Stopped")
+}
+
+// newLogStore creates the chat log store configured by logs.store. Both are
+// nil if logging is disabled. files is set if logs are stored in files.
+func newLogStore() (store chatlog.Store, files *chatlog.Files) {
+	retention := viper.GetDuration("logs.retention")
+
+	switch name := viper.GetString("logs.store"); name {
+	case "":
+		return nil, nil
+	case "mongo":
+		if err := stateClient.EnsureLogIndexes(retention); err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Could not create log indexes")
+		}
+		return stateClient, nil
+	case "files":
+		files, err := chatlog.NewFiles(viper.GetString("logs.dir"), retention)
+		if err != nil {
+			log.Fatal().
+				Err(err).
+				Msg("Could not create log directory")
+		}
+		return files, files
+	default:
+		log.Fatal().
+			Str("store", name).
+			Msg("Unknown logs.store. Use mongo or files.")
+	}
+
+	return nil, nil
+}
+
+// readConfig reads the config file and sets defaults.
+func readConfig() {
+	// Viper {{{
+	viper.SetConfigType("toml") // toml is nice
+	viper.SetConfigName("config")
+	viper.AddConfigPath("/etc/chb3") // config location
+	viper.AddConfigPath(".")         // also look in the working directory
+
+	// Not sure what to use this for yet.
+	viper.SetEnvPrefix("CHB3")
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			// Config file not found
+			log.Fatal().
+				Err(err).
+				Msg("Error config not found.")
+		}
+		log.Fatal().
+			Err(err).
+			Msg("Error reading config.")
+	}
+	// }}}
+
+	viper.SetDefault("http.listen", "localhost:8080")
+	viper.SetDefault("scripts.dir", "/etc/chb3/actions")
+	viper.SetDefault("scripts.steps", 1000000)
+	viper.SetDefault("scripts.timeout", time.Second)
+	viper.SetDefault("scripts.reload", 10*time.Second)
+	viper.SetDefault("health.silence", 10*time.Minute)
+	viper.SetDefault("health.disconnected", 5*time.Minute)
+	viper.SetDefault("shutdown.timeout", 10*time.Second)
+	viper.SetDefault("reconnect.min", time.Second)
+	viper.SetDefault("reconnect.max", 5*time.Minute)
+	viper.SetDefault("stats.flush", time.Minute)
+	viper.SetDefault("markov.save", 10*time.Minute)
+	viper.SetDefault("raffle.confirm", cmd.RaffleTimeout)
+	viper.SetDefault("logs.dir", "/var/lib/chb3/logs")
+	viper.SetDefault("logs.retention", 30*24*time.Hour)
+	config.SetDefaults(viper.GetViper())
+}
+
+func newHelixClient() (*helix.Client, error) {
+	return helix.NewClient(&helix.Options{
+		ClientID:     viper.GetString("twitch.clientid"),
+		ClientSecret: viper.GetString("twitch.secret"),
+		UserAgent:    "ChronophylosBot/" + buildinfo.Version(),
+		RedirectURI:  "https://localhost",
+		Scopes:       []string{"chat:read", "chat:edit", "channel:moderate", "moderation:read", "channel_editor", raffle.FollowerScope},
+	})
+}
+
+func generateDocs(format, output string) {
+	w := os.Stdout
+
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatal().
+				Err(err).
+				Str("output", output).
+				Msg("Could not create output file")
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := cmd.WriteDocs(w, format); err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("Could not generate docs")
 	}
 }
 
 // check for voicemails {{{
-func checkForVoicemails(username, channel string) {
+func checkForVoicemails(user *state.User, channel string) {
+	username := user.Name
 
 	voicemails, err := stateClient.CheckForVoicemails(username)
 	if err != nil {
@@ -339,7 +683,20 @@ func checkForVoicemails(username, channel string) {
 			Str("username", username).
 			Msg("Replaying Voicemails")
 
-		messages := []string{"@" + username + ", " + pluralize("message", int64(len(voicemails))) + " for you: "}
+		language := user.Language
+		if language == "" {
+			c, err := stateClient.GetChannel(channel)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("channel", channel).
+					Msg("Getting channel language")
+			}
+			language = c.Language
+		}
+		language = i18n.Pick(language)
+
+		messages := []string{i18n.Plural(language, "voicemail.replay", len(voicemails), username)}
 		i := 0
 		noDelimiter := true
 		var delimiter string
@@ -394,10 +751,3 @@ func censor(text string) string {
 	}
 	return "[REDACTED]"
 }
-
-func pluralize(text string, times int64) string {
-	if times > 1 {
-		return strconv.FormatInt(times, 10) + " " + text + "s"
-	}
-	return "one " + text
-}
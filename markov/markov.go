@@ -0,0 +1,207 @@
+// Package markov generates messages with markov chains learned from chat.
+package markov
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Start and end of a message in a chain. Words are never empty so they can't
+// clash.
+const boundary = ""
+
+// MaxWords is the number of different words a chain learns. Words seen after
+// that are ignored so a chain can't grow forever.
+var MaxWords = 20000
+
+// Chain is a first order markov chain of words. It is safe for concurrent
+// use.
+type Chain struct {
+	mu    sync.RWMutex
+	next  map[string]map[string]int
+	dirty bool
+}
+
+// NewChain creates an empty chain.
+func NewChain() *Chain {
+	return &Chain{next: map[string]map[string]int{}}
+}
+
+// Learn adds the words of text to the chain.
+func (c *Chain) Learn(text string) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev := boundary
+	for _, word := range append(words, boundary) {
+		if _, ok := c.next[word]; !ok && word != boundary {
+			if len(c.next) >= MaxWords {
+				return
+			}
+			c.next[word] = map[string]int{}
+		}
+
+		if _, ok := c.next[prev]; !ok {
+			c.next[prev] = map[string]int{}
+		}
+		c.next[prev][word]++
+		c.dirty = true
+
+		prev = word
+	}
+}
+
+// Knows reports wheather the chain learned word.
+func (c *Chain) Knows(word string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.next[word]
+	return ok && word != boundary
+}
+
+// Generate returns a message of at most max words. If seed is not empty the
+// message starts with it. It returns an empty string if the chain doesn't
+// know seed or didn't learn anything yet.
+func (c *Chain) Generate(seed string, max int, rnd *rand.Rand) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var words []string
+	word := boundary
+	if seed != "" {
+		if _, ok := c.next[seed]; !ok {
+			return ""
+		}
+		words = append(words, seed)
+		word = seed
+	}
+
+	for len(words) < max {
+		word = pick(c.next[word], rnd)
+		if word == boundary {
+			break
+		}
+		words = append(words, word)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// pick returns a random word of counts weighted by its count.
+func pick(counts map[string]int, rnd *rand.Rand) string {
+	var total int
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return boundary
+	}
+
+	// Map order is random but the weights are not
+	i := rnd.Intn(total)
+	for word, n := range counts {
+		if i < n {
+			return word
+		}
+		i -= n
+	}
+
+	return boundary
+}
+
+// MaxPartSize is the size in bytes a chain is split at when it is stored. A
+// mongo document can't be larger than 16 MB.
+var MaxPartSize = 4 << 20
+
+// marshalParts encodes the learned words in parts of at most max bytes. A
+// word with more followers than fit into max gets a part of its own.
+func (c *Chain) marshalParts(max int) ([][]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	words := make([]string, 0, len(c.next))
+	for word := range c.next {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	var parts [][]byte
+	part := map[string]map[string]int{}
+	size := 2
+	flush := func() error {
+		data, err := json.Marshal(part)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, data)
+		part = map[string]map[string]int{}
+		size = 2
+		return nil
+	}
+
+	for _, word := range words {
+		key, err := json.Marshal(word)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(c.next[word])
+		if err != nil {
+			return nil, err
+		}
+
+		// Key, colon, value and comma
+		n := len(key) + len(value) + 2
+		if len(part) > 0 && size+n > max {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		part[word] = c.next[word]
+		size += n
+	}
+
+	if len(part) > 0 || len(parts) == 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	return parts, nil
+}
+
+// unmarshalPart adds the learned words of a part encoded by marshalParts.
+func (c *Chain) unmarshalPart(data []byte) error {
+	next := map[string]map[string]int{}
+	if err := json.Unmarshal(data, &next); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for word, counts := range next {
+		c.next[word] = counts
+	}
+
+	return nil
+}
+
+// takeDirty reports wheather the chain changed since the last call.
+func (c *Chain) takeDirty() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dirty := c.dirty
+	c.dirty = false
+
+	return dirty
+}
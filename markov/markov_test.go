@@ -0,0 +1,181 @@
+package markov
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	c := NewChain()
+	c.Learn("the cat sat on the mat")
+	c.Learn("the dog sat on the log")
+	rnd := rand.New(rand.NewSource(1))
+
+	var tests = []struct {
+		name string
+		seed string
+	}{
+		{"random", ""},
+		{"seed", "dog"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				text := c.Generate(test.seed, 10, rnd)
+				words := strings.Fields(text)
+				if assert.NotEmpty(t, words) {
+					assert.LessOrEqual(t, len(words), 10)
+					if test.seed != "" {
+						assert.Equal(t, test.seed, words[0])
+					}
+					// Every chain ends on a word a message ended with
+					last := words[len(words)-1]
+					assert.True(t, len(words) == 10 || last == "mat" || last == "log", text)
+				}
+			}
+		})
+	}
+
+	assert.Empty(t, c.Generate("bird", 10, rnd))
+	assert.Empty(t, NewChain().Generate("", 10, rnd))
+}
+
+type fakeStore map[string][][]byte
+
+func (s fakeStore) GetMarkovChains() ([]state.MarkovChain, error) {
+	var list []state.MarkovChain
+	for key, parts := range s {
+		for i, data := range parts {
+			list = append(list, state.MarkovChain{Key: key, Part: i, Data: data})
+		}
+	}
+	return list, nil
+}
+
+func (s fakeStore) SetMarkovChain(key string, parts [][]byte) error {
+	if key == UserKey("broken") {
+		return errors.New("document too large")
+	}
+	s[key] = parts
+	return nil
+}
+
+func (s fakeStore) RemoveMarkovChain(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func TestModel(t *testing.T) {
+	store := fakeStore{}
+
+	m := NewModel(store)
+	m.Learn("chronophylos", "1", "hello world")
+	require.NoError(t, m.Save())
+	assert.Len(t, store, 2)
+
+	// Unchanged chains are not saved again
+	delete(store, ChannelKey("chronophylos"))
+	require.NoError(t, m.Save())
+	assert.Len(t, store, 1)
+
+	loaded := NewModel(store)
+	require.NoError(t, loaded.Load())
+	assert.Nil(t, loaded.Chain(ChannelKey("chronophylos")))
+	if chain := loaded.Chain(UserKey("1")); assert.NotNil(t, chain) {
+		assert.True(t, chain.Knows("hello"))
+		assert.Equal(t, "hello world", chain.Generate("hello", 10, rand.New(rand.NewSource(1))))
+	}
+
+	require.NoError(t, loaded.Forget(UserKey("1")))
+	assert.Nil(t, loaded.Chain(UserKey("1")))
+	assert.Empty(t, store)
+}
+
+func TestLearnable(t *testing.T) {
+	assert.True(t, Learnable("hello world"))
+	assert.False(t, Learnable("~markov"))
+	assert.False(t, Learnable("!join"))
+	assert.False(t, Learnable(""))
+}
+
+func TestModelRelearn(t *testing.T) {
+	store := fakeStore{}
+
+	m := NewModel(store)
+	m.Learn("chronophylos", "1", "secret words")
+	m.Learn("chronophylos", "2", "hello world")
+	m.Learn("other", "2", "hello there")
+	require.NoError(t, m.Save())
+
+	assert.ElementsMatch(t, []string{"chronophylos", "other"}, m.Channels())
+
+	m.Relearn(ChannelKey("chronophylos"), []string{"hello world"})
+	assert.False(t, m.Chain(ChannelKey("chronophylos")).Knows("secret"))
+	assert.True(t, m.Chain(ChannelKey("chronophylos")).Knows("hello"))
+
+	// The relearned chain is saved
+	delete(store, ChannelKey("chronophylos"))
+	require.NoError(t, m.Save())
+	assert.Contains(t, store, ChannelKey("chronophylos"))
+}
+
+func TestModelSaveParts(t *testing.T) {
+	defer func(max int) { MaxPartSize = max }(MaxPartSize)
+	MaxPartSize = 64
+
+	store := fakeStore{}
+
+	m := NewModel(store)
+	m.Learn("chronophylos", "1", "the quick brown fox jumps over the lazy dog")
+	m.Learn("chronophylos", "broken", "hello world")
+
+	// The broken chain does not keep the others from being saved
+	assert.Error(t, m.Save())
+	assert.Contains(t, store, ChannelKey("chronophylos"))
+	assert.Contains(t, store, UserKey("1"))
+	assert.Greater(t, len(store[UserKey("1")]), 1, "large chains are split")
+	for _, part := range store[UserKey("1")] {
+		assert.LessOrEqual(t, len(part), MaxPartSize)
+	}
+
+	// Failed chains are saved again
+	assert.Error(t, m.Save())
+
+	loaded := NewModel(store)
+	require.NoError(t, loaded.Load())
+	if chain := loaded.Chain(UserKey("1")); assert.NotNil(t, chain) {
+		assert.Equal(t, m.Chain(UserKey("1")).next, chain.next)
+	}
+}
+
+func TestModelStale(t *testing.T) {
+	m := NewModel(fakeStore{})
+	m.Learn("chronophylos", "1", "secret words")
+
+	var calls int
+	m.Source = func(channel string) ([]string, error) {
+		calls++
+		assert.Equal(t, "chronophylos", channel)
+		return []string{"hello world"}, nil
+	}
+
+	// Marking a chain twice learns it once
+	m.Stale("chronophylos")
+	m.Stale("chronophylos")
+	assert.True(t, m.Chain(ChannelKey("chronophylos")).Knows("secret"), "stale chains are learned later")
+
+	m.relearnStale()
+	assert.Equal(t, 1, calls)
+	assert.False(t, m.Chain(ChannelKey("chronophylos")).Knows("secret"))
+	assert.True(t, m.Chain(ChannelKey("chronophylos")).Knows("hello"))
+
+	m.relearnStale()
+	assert.Equal(t, 1, calls)
+}
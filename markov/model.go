@@ -0,0 +1,260 @@
+package markov
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/rs/zerolog/log"
+)
+
+// Store stores chains, eg. *state.Client.
+type Store interface {
+	GetMarkovChains() ([]state.MarkovChain, error)
+	SetMarkovChain(key string, parts [][]byte) error
+	RemoveMarkovChain(key string) error
+}
+
+// ChannelKey and UserKey return the keys of the chain of a channel and of the
+// user with a twitch ID.
+func ChannelKey(channel string) string { return "#" + channel }
+func UserKey(userID string) string     { return "@" + userID }
+
+// MaxRelearnLines is how many of the newest lines a stale channel chain is
+// learned again from.
+const MaxRelearnLines = 50000
+
+// Model are the chains of all channels and users.
+type Model struct {
+	// Source returns the texts the stale chain of channel is learned again
+	// from. Stale chains are kept if it is nil.
+	Source func(channel string) ([]string, error)
+
+	store Store
+
+	mu     sync.RWMutex
+	chains map[string]*Chain
+	stale  map[string]bool
+}
+
+// NewModel creates an empty model persisted in store.
+func NewModel(store Store) *Model {
+	return &Model{
+		store:  store,
+		chains: map[string]*Chain{},
+		stale:  map[string]bool{},
+	}
+}
+
+// Learnable reports wheather text should be learned. Commands would make the
+// chains generate commands.
+func Learnable(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return text != "" && !unicode.IsPunct(r) && !unicode.IsSymbol(r)
+}
+
+// Learn adds text written by the user with the twitch ID userID in channel to
+// the chains of the channel and the user.
+func (m *Model) Learn(channel, userID, text string) {
+	m.chain(ChannelKey(channel), true).Learn(text)
+	m.chain(UserKey(userID), true).Learn(text)
+}
+
+// Chain returns the chain with key or nil if there is none.
+func (m *Model) Chain(key string) *Chain {
+	return m.chain(key, false)
+}
+
+func (m *Model) chain(key string, create bool) *Chain {
+	m.mu.RLock()
+	chain, ok := m.chains[key]
+	m.mu.RUnlock()
+	if ok || !create {
+		return chain
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if chain, ok := m.chains[key]; ok {
+		return chain
+	}
+	chain = NewChain()
+	m.chains[key] = chain
+
+	return chain
+}
+
+// Channels returns the channels that have a chain.
+func (m *Model) Channels() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var channels []string
+	for key := range m.chains {
+		if strings.HasPrefix(key, ChannelKey("")) {
+			channels = append(channels, strings.TrimPrefix(key, ChannelKey("")))
+		}
+	}
+	return channels
+}
+
+// Relearn replaces the chain with key by a chain learned from texts. It is
+// saved with the next Save.
+func (m *Model) Relearn(key string, texts []string) {
+	chain := NewChain()
+	for _, text := range texts {
+		chain.Learn(text)
+	}
+	chain.dirty = true
+
+	m.mu.Lock()
+	m.chains[key] = chain
+	m.mu.Unlock()
+}
+
+// Stale marks the chain of channel to be learned again from Source before the
+// next periodic save. Marking it again before that does nothing.
+func (m *Model) Stale(channel string) {
+	m.mu.Lock()
+	m.stale[channel] = true
+	m.mu.Unlock()
+}
+
+// relearnStale learns the stale channel chains again.
+func (m *Model) relearnStale() {
+	m.mu.Lock()
+	stale := m.stale
+	m.stale = map[string]bool{}
+	m.mu.Unlock()
+
+	if m.Source == nil {
+		return
+	}
+
+	for channel := range stale {
+		texts, err := m.Source(channel)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("channel", channel).
+				Msg("Getting lines to learn markov chain again")
+			continue
+		}
+		if len(texts) > MaxRelearnLines {
+			texts = texts[len(texts)-MaxRelearnLines:]
+		}
+
+		log.Info().
+			Str("channel", channel).
+			Int("lines", len(texts)).
+			Msg("Learning markov chain again")
+		m.Relearn(ChannelKey(channel), texts)
+	}
+}
+
+// Forget removes the chain with key from the model and the store.
+func (m *Model) Forget(key string) error {
+	m.mu.Lock()
+	delete(m.chains, key)
+	m.mu.Unlock()
+
+	return m.store.RemoveMarkovChain(key)
+}
+
+// Load replaces all chains with the stored ones.
+func (m *Model) Load() error {
+	list, err := m.store.GetMarkovChains()
+	if err != nil {
+		return err
+	}
+
+	chains := map[string]*Chain{}
+	for _, stored := range list {
+		chain, ok := chains[stored.Key]
+		if !ok {
+			chain = NewChain()
+			chains[stored.Key] = chain
+		}
+		if err := chain.unmarshalPart(stored.Data); err != nil {
+			return fmt.Errorf("decoding chain %s part %d: %v", stored.Key, stored.Part, err)
+		}
+	}
+
+	m.mu.Lock()
+	m.chains = chains
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Save stores every chain that changed since it was saved last. Chains that
+// could not be stored are saved again with the next Save.
+func (m *Model) Save() error {
+	m.mu.RLock()
+	chains := make(map[string]*Chain, len(m.chains))
+	for key, chain := range m.chains {
+		chains[key] = chain
+	}
+	m.mu.RUnlock()
+
+	var failed []string
+	for key, chain := range chains {
+		if !chain.takeDirty() {
+			continue
+		}
+
+		if err := m.save(key, chain); err != nil {
+			chain.mu.Lock()
+			chain.dirty = true
+			chain.mu.Unlock()
+
+			log.Error().
+				Err(err).
+				Str("key", key).
+				Msg("Saving markov chain")
+			failed = append(failed, key)
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("saving chains %s failed", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (m *Model) save(key string, chain *Chain) error {
+	parts, err := chain.marshalParts(MaxPartSize)
+	if err != nil {
+		return err
+	}
+
+	return m.store.SetMarkovChain(key, parts)
+}
+
+// Run learns stale chains again and saves the model every interval until ctx
+// is done. Call Save afterwards to store the last changes.
+func (m *Model) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.relearnStale()
+		if err := m.Save(); err != nil {
+			log.Error().Err(err).Msg("Saving markov chains")
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package metrics defines the prometheus metrics of the bot.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "chb3"
+
+// Metrics
+var (
+	MessagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_received_total",
+		Help:      "Number of chat messages received per channel.",
+	}, []string{"channel"})
+
+	SwearsFiltered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "swear_filter_drops_total",
+		Help:      "Number of chat messages dropped by the swear filter per channel.",
+	}, []string{"channel"})
+
+	SpamDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spam_detected_total",
+		Help:      "Number of chat messages detected as spam per channel and check.",
+	}, []string{"channel", "check"})
+
+	ActionsMatched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "actions_matched_total",
+		Help:      "Number of messages matched by an action.",
+	}, []string{"action"})
+
+	ActionsRun = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "actions_run_total",
+		Help:      "Number of times an action was run.",
+	}, []string{"action"})
+
+	ActionsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "actions_failed_total",
+		Help:      "Number of times an action returned an error.",
+	}, []string{"action"})
+
+	RunActionsDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "run_actions_duration_seconds",
+		Help:      "Time it took to run all actions for a message.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	StateDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "state_duration_seconds",
+		Help:      "Time it took to call a method of the state client.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_client_requests_total",
+		Help:      "Number of outbound HTTP requests by service and status code.",
+	}, []string{"service", "code"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_client_request_duration_seconds",
+		Help:      "Time it took to perform an outbound HTTP request by service.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service"})
+)
+
+// Handler serves all metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveState starts timing the state client method method. Call the
+// returned function once the method is done:
+//
+//	defer metrics.ObserveState("BumpUser")()
+func ObserveState(method string) func() {
+	timer := prometheus.NewTimer(StateDuration.WithLabelValues(method))
+	return func() { timer.ObserveDuration() }
+}
+
+type transport struct {
+	service string
+	base    http.RoundTripper
+}
+
+// Transport wraps base and counts all requests for service. If base is nil
+// http.DefaultTransport is used.
+func Transport(service string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{service: service, base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	HTTPDuration.WithLabelValues(t.service).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	HTTPRequests.WithLabelValues(t.service, code).Inc()
+
+	return resp, err
+}
@@ -11,11 +11,15 @@ import (
 
 type Client struct {
 	UserAgent string
+	// Transport is used for all requests. If nil http.DefaultTransport is
+	// used.
+	Transport http.RoundTripper
 }
 
 func (c *Client) GetPlace(location string) (*Place, error) {
 	client := &http.Client{
-		Timeout: time.Second * 30,
+		Timeout:   time.Second * 30,
+		Transport: c.Transport,
 	}
 
 	req, err := http.NewRequest("GET", getSearchURL(location), nil)
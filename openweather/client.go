@@ -138,6 +138,11 @@ func NewClient(appid, userAgent string) *Client {
 	}
 }
 
+// SetTransport sets the transport used for all requests.
+func (c *Client) SetTransport(transport http.RoundTripper) {
+	c.httpClient.Transport = transport
+}
+
 func (c *Client) request(url string, params url.Values) ([]byte, error) {
 	params.Set("appid", c.appid)
 	params.Set("lang", "de")
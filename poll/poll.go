@@ -0,0 +1,242 @@
+// Package poll runs chat polls. Every channel runs at most one poll at a
+// time.
+package poll
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chronophylos/chb3/filter"
+)
+
+// ErrRunning is returned by Start if the channel already runs a poll.
+var ErrRunning = errors.New("a poll is already running")
+
+// Result are the standings of a poll.
+type Result struct {
+	Channel  string
+	Question string
+	Options  []string
+	// Counts are the votes per option.
+	Counts  []int
+	Voters  int
+	By      string
+	Started time.Time
+	Ends    time.Time
+}
+
+// Winners returns the indices of the options with the most votes. It is
+// empty if nobody voted.
+func (r Result) Winners() []int {
+	var max int
+	var winners []int
+	for i, n := range r.Counts {
+		switch {
+		case n == 0 || n < max:
+		case n > max:
+			max = n
+			winners = []int{i}
+		default:
+			winners = append(winners, i)
+		}
+	}
+	return winners
+}
+
+type poll struct {
+	Result
+
+	votes   map[string]int
+	changed bool
+	stop    chan struct{}
+}
+
+func (p *poll) result() Result {
+	r := p.Result
+	r.Counts = make([]int, len(p.Options))
+	for _, option := range p.votes {
+		r.Counts[option]++
+	}
+	r.Voters = len(p.votes)
+	return r
+}
+
+// Polls runs the polls of all channels.
+type Polls struct {
+	// Interval is how often OnUpdate is called while votes come in.
+	Interval time.Duration
+	// OnUpdate is called with the live standings every Interval if somebody
+	// voted.
+	OnUpdate func(r Result)
+	// OnEnd is called with the final result once a poll ended.
+	OnEnd func(r Result)
+
+	mu    sync.Mutex
+	polls map[string]*poll
+}
+
+// New creates a Polls announcing the standings every interval.
+func New(interval time.Duration) *Polls {
+	return &Polls{
+		Interval: interval,
+		polls:    map[string]*poll{},
+	}
+}
+
+// Start starts a poll in channel with question and options which ends after
+// d.
+func (p *Polls) Start(channel, by, question string, options []string, now time.Time, d time.Duration) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.polls[channel]; ok {
+		return Result{}, ErrRunning
+	}
+
+	running := &poll{
+		Result: Result{
+			Channel:  channel,
+			Question: question,
+			Options:  options,
+			By:       by,
+			Started:  now,
+			Ends:     now.Add(d),
+		},
+		votes: map[string]int{},
+		stop:  make(chan struct{}),
+	}
+	p.polls[channel] = running
+
+	go p.run(running, d)
+
+	return running.result(), nil
+}
+
+func (p *Polls) run(running *poll, d time.Duration) {
+	end := time.NewTimer(d)
+	defer end.Stop()
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-running.stop:
+			return
+		case <-end.C:
+			p.finish(running)
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			changed := running.changed
+			running.changed = false
+			result := running.result()
+			p.mu.Unlock()
+
+			if changed && p.OnUpdate != nil {
+				p.OnUpdate(result)
+			}
+		}
+	}
+}
+
+// Vote counts text written by the user with the twitch ID userID in channel
+// as a vote if it is the number or the text of an option. A user who votes
+// again changes their vote. It reports wheather text was a vote.
+func (p *Polls) Vote(channel, userID, text string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	running, ok := p.polls[channel]
+	if !ok {
+		return false
+	}
+
+	option, ok := parseVote(running.Options, text)
+	if !ok {
+		return false
+	}
+
+	if previous, ok := running.votes[userID]; !ok || previous != option {
+		running.votes[userID] = option
+		running.changed = true
+	}
+
+	return true
+}
+
+// parseVote returns the index of the option text votes for.
+func parseVote(options []string, text string) (int, bool) {
+	text = strings.TrimSpace(text)
+
+	if n, err := strconv.Atoi(text); err == nil {
+		if n < 1 || n > len(options) {
+			return 0, false
+		}
+		return n - 1, true
+	}
+
+	normalized := filter.Normalize(text)
+	for i, option := range options {
+		if filter.Normalize(option) == normalized {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// Current returns the standings of the poll in channel.
+func (p *Polls) Current(channel string) (Result, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	running, ok := p.polls[channel]
+	if !ok {
+		return Result{}, false
+	}
+
+	return running.result(), true
+}
+
+// End ends the poll in channel and calls OnEnd. It reports wheather a poll
+// was running.
+func (p *Polls) End(channel string) bool {
+	p.mu.Lock()
+	running, ok := p.polls[channel]
+	p.mu.Unlock()
+
+	return ok && p.finish(running)
+}
+
+// finish ends running unless it already ended.
+func (p *Polls) finish(running *poll) bool {
+	p.mu.Lock()
+	if p.polls[running.Channel] != running {
+		p.mu.Unlock()
+		return false
+	}
+	delete(p.polls, running.Channel)
+	close(running.stop)
+	result := running.result()
+	p.mu.Unlock()
+
+	if p.OnEnd != nil {
+		p.OnEnd(result)
+	}
+
+	return true
+}
+
+// Close stops all polls without calling OnEnd.
+func (p *Polls) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for channel, running := range p.polls {
+		close(running.stop)
+		delete(p.polls, channel)
+	}
+}
@@ -0,0 +1,96 @@
+package poll
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVote(t *testing.T) {
+	options := []string{"Pizza", "Pasta", "Döner"}
+
+	var tests = []struct {
+		text   string
+		option int
+		ok     bool
+	}{
+		{"1", 0, true},
+		{" 3 ", 2, true},
+		{"4", 0, false},
+		{"0", 0, false},
+		{"pasta", 1, true},
+		{"DONER", 2, true},
+		{"pizza please", 0, false},
+	}
+
+	for _, test := range tests {
+		option, ok := parseVote(options, test.text)
+		assert.Equal(t, test.ok, ok, test.text)
+		assert.Equal(t, test.option, option, test.text)
+	}
+}
+
+func TestPolls(t *testing.T) {
+	p := New(time.Hour)
+	ended := make(chan Result, 1)
+	p.OnEnd = func(r Result) { ended <- r }
+
+	_, err := p.Start("chronophylos", "someone", "Food?", []string{"Pizza", "Pasta"}, time.Now(), time.Hour)
+	require.NoError(t, err)
+	_, err = p.Start("chronophylos", "someone", "Again?", []string{"Yes", "No"}, time.Now(), time.Hour)
+	assert.Equal(t, ErrRunning, err)
+
+	assert.True(t, p.Vote("chronophylos", "1", "1"))
+	assert.True(t, p.Vote("chronophylos", "2", "pasta"))
+	assert.True(t, p.Vote("chronophylos", "1", "2"), "changing the vote")
+	assert.False(t, p.Vote("chronophylos", "3", "hello"))
+	assert.False(t, p.Vote("someone", "3", "1"))
+
+	current, ok := p.Current("chronophylos")
+	require.True(t, ok)
+	assert.Equal(t, []int{0, 2}, current.Counts)
+	assert.Equal(t, 2, current.Voters)
+
+	assert.True(t, p.End("chronophylos"))
+	assert.False(t, p.End("chronophylos"))
+
+	result := <-ended
+	assert.Equal(t, []int{1}, result.Winners())
+	assert.Equal(t, "Food?", result.Question)
+}
+
+func TestPollsTimer(t *testing.T) {
+	p := New(10 * time.Millisecond)
+	updated := make(chan Result, 10)
+	ended := make(chan Result, 1)
+	p.OnUpdate = func(r Result) { updated <- r }
+	p.OnEnd = func(r Result) { ended <- r }
+
+	_, err := p.Start("chronophylos", "someone", "Food?", []string{"Pizza", "Pasta"}, time.Now(), 100*time.Millisecond)
+	require.NoError(t, err)
+	p.Vote("chronophylos", "1", "1")
+
+	select {
+	case r := <-updated:
+		assert.Equal(t, []int{1, 0}, r.Counts)
+	case <-time.After(time.Second):
+		t.Fatal("no update")
+	}
+
+	select {
+	case r := <-ended:
+		assert.Equal(t, 1, r.Voters)
+	case <-time.After(time.Second):
+		t.Fatal("poll did not end")
+	}
+
+	_, ok := p.Current("chronophylos")
+	assert.False(t, ok)
+}
+
+func TestWinners(t *testing.T) {
+	assert.Empty(t, Result{Counts: []int{0, 0}}.Winners())
+	assert.Equal(t, []int{0, 2}, Result{Counts: []int{3, 1, 3}}.Winners())
+}
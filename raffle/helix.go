@@ -0,0 +1,158 @@
+package raffle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// HelixURL is the base URL of the twitch API.
+const HelixURL = "https://api.twitch.tv/helix"
+
+// ErrUnknownUser is returned by Helix.CreatedAt if twitch does not know the
+// user.
+var ErrUnknownUser = errors.New("unknown user")
+
+// FollowerScope is the scope the token needs to check followers. The bot must
+// be a moderator of the channel.
+const FollowerScope = "moderator:read:followers"
+
+// ErrMissingFollowerScope is returned by Helix.Follows if the token can't be
+// used to check followers.
+var ErrMissingFollowerScope = errors.New("the token is missing the scope " + FollowerScope + ", run chb3 auth again")
+
+// apiError is a response of the twitch API that is not OK.
+type apiError struct {
+	Path    string
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %d %s", e.Path, e.Status, http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("%s: %d %s", e.Path, e.Status, e.Message)
+}
+
+// Helix is a Checker using the twitch API. The helix module does not return
+// when an account was created, so the API is called directly.
+type Helix struct {
+	ClientID string
+	// Token returns the current access token.
+	Token func() string
+	// Transport is used for all requests. If nil http.DefaultTransport is
+	// used.
+	Transport http.RoundTripper
+	// BaseURL defaults to HelixURL.
+	BaseURL string
+
+	mu      sync.Mutex
+	created map[string]time.Time
+}
+
+// NewHelix creates a Helix for the app clientID calling token before every
+// request.
+func NewHelix(clientID string, token func() string) *Helix {
+	return &Helix{
+		ClientID: clientID,
+		Token:    token,
+		BaseURL:  HelixURL,
+		created:  map[string]time.Time{},
+	}
+}
+
+// CreatedAt returns when the account with the twitch ID userID was created.
+// The dates are cached since they never change.
+func (h *Helix) CreatedAt(userID string) (time.Time, error) {
+	h.mu.Lock()
+	created, ok := h.created[userID]
+	h.mu.Unlock()
+	if ok {
+		return created, nil
+	}
+
+	var resp struct {
+		Data []struct {
+			ID        string    `json:"id"`
+			CreatedAt time.Time `json:"created_at"`
+		} `json:"data"`
+	}
+	if err := h.get("/users", url.Values{"id": {userID}}, &resp); err != nil {
+		return time.Time{}, err
+	}
+	if len(resp.Data) == 0 {
+		return time.Time{}, ErrUnknownUser
+	}
+	created = resp.Data[0].CreatedAt
+
+	h.mu.Lock()
+	h.created[userID] = created
+	h.mu.Unlock()
+
+	return created, nil
+}
+
+// Follows reports wheather userID follows channelID. The token needs
+// FollowerScope.
+func (h *Helix) Follows(userID, channelID string) (bool, error) {
+	var resp struct {
+		Data []struct {
+			UserID string `json:"user_id"`
+		} `json:"data"`
+	}
+	query := url.Values{"broadcaster_id": {channelID}, "user_id": {userID}}
+	if err := h.get("/channels/followers", query, &resp); err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && (apiErr.Status == http.StatusUnauthorized || apiErr.Status == http.StatusForbidden) {
+			return false, fmt.Errorf("%w: %v", ErrMissingFollowerScope, err)
+		}
+		return false, err
+	}
+
+	for _, follower := range resp.Data {
+		if follower.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (h *Helix) get(path string, query url.Values, v interface{}) error {
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: h.Transport,
+	}
+
+	req, err := http.NewRequest("GET", h.BaseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Client-ID", h.ClientID)
+	if h.Token != nil {
+		req.Header.Set("Authorization", "Bearer "+h.Token())
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &apiError{Path: path, Status: resp.StatusCode}
+		var body struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&body) == nil {
+			apiErr.Message = body.Message
+		}
+		return apiErr
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,435 @@
+// Package raffle runs giveaways in chat. Every channel runs at most one
+// raffle at a time. Chatters enter by writing the keyword and winners are
+// drawn with crypto/rand.
+package raffle
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Errors returned by Raffles.
+var (
+	ErrRunning    = errors.New("a raffle is already running")
+	ErrNotRunning = errors.New("no raffle is running")
+	ErrNoEntrants = errors.New("nobody is left to draw")
+)
+
+// Entrant is a user who entered a raffle.
+type Entrant struct {
+	UserID   string
+	UserName string
+	Time     time.Time
+}
+
+// Winner is a drawn entrant.
+type Winner struct {
+	Entrant
+	Drawn time.Time
+	// Confirmed is set once the winner wrote in chat.
+	Confirmed bool
+	// Expired is set if the winner did not confirm in time.
+	Expired bool
+}
+
+// Pending reports wheather w still has to confirm.
+func (w Winner) Pending() bool {
+	return !w.Confirmed && !w.Expired
+}
+
+// Raffle is the state of a raffle. Entrants and Winners are the audit record
+// of the draw.
+type Raffle struct {
+	Channel string
+	Keyword string
+	Rules   Rules
+	// By is the name of the user who started the raffle.
+	By      string
+	Started time.Time
+	// Closed is set once the first winner was drawn. Nobody can enter after
+	// that.
+	Closed   bool
+	Ended    time.Time
+	Entrants []Entrant
+	Winners  []Winner
+}
+
+type raffle struct {
+	Raffle
+
+	entered map[string]bool
+	drawn   map[string]bool
+	timers  map[string]*time.Timer
+	// checking are the users whose rules are being checked and ineligible
+	// the users who did not pass them.
+	checking   map[string]bool
+	ineligible map[string]bool
+}
+
+func (r *raffle) snapshot() Raffle {
+	s := r.Raffle
+	s.Entrants = append([]Entrant(nil), r.Entrants...)
+	s.Winners = append([]Winner(nil), r.Winners...)
+	return s
+}
+
+func (r *raffle) stopTimers() {
+	for userID, timer := range r.timers {
+		timer.Stop()
+		delete(r.timers, userID)
+	}
+}
+
+// Raffles runs the raffles of all channels.
+type Raffles struct {
+	// Timeout is how long a winner has to write in chat before somebody else
+	// is drawn. Winners don't have to confirm if it is zero.
+	Timeout time.Duration
+	// Rand is the source of randomness for draws. It defaults to
+	// crypto/rand.Reader.
+	Rand io.Reader
+
+	// OnConfirm is called when a winner confirmed.
+	OnConfirm func(r Raffle, w Winner)
+	// OnExpire is called when a winner did not confirm in time. next is the
+	// winner drawn instead or nil if nobody is left.
+	OnExpire func(r Raffle, expired Winner, next *Winner)
+	// OnChange is called every time a raffle started, was drawn from, a
+	// winner confirmed or expired and when it ended. Calls are made in the
+	// order of the changes.
+	OnChange func(r Raffle)
+
+	mu      sync.Mutex
+	raffles map[string]*raffle
+
+	// changeMu keeps callbacks in order without holding mu while they run.
+	changeMu sync.Mutex
+}
+
+// New creates a Raffles whose winners have to confirm within timeout.
+func New(timeout time.Duration) *Raffles {
+	return &Raffles{
+		Timeout: timeout,
+		Rand:    rand.Reader,
+		raffles: map[string]*raffle{},
+	}
+}
+
+// unlock unlocks mu and runs callbacks in order with other changes.
+func (p *Raffles) unlock(callbacks ...func()) {
+	p.changeMu.Lock()
+	p.mu.Unlock()
+	defer p.changeMu.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+func (p *Raffles) changed(r Raffle) func() {
+	return func() {
+		if p.OnChange != nil {
+			p.OnChange(r)
+		}
+	}
+}
+
+// Start starts a raffle in channel which users enter by writing keyword.
+func (p *Raffles) Start(channel, by, keyword string, rules Rules, now time.Time) (Raffle, error) {
+	p.mu.Lock()
+
+	if _, ok := p.raffles[channel]; ok {
+		p.mu.Unlock()
+		return Raffle{}, ErrRunning
+	}
+
+	running := &raffle{
+		Raffle: Raffle{
+			Channel: channel,
+			Keyword: keyword,
+			Rules:   rules,
+			By:      by,
+			Started: now,
+		},
+		entered:    map[string]bool{},
+		drawn:      map[string]bool{},
+		timers:     map[string]*time.Timer{},
+		checking:   map[string]bool{},
+		ineligible: map[string]bool{},
+	}
+	p.raffles[channel] = running
+
+	snapshot := running.snapshot()
+	p.unlock(p.changed(snapshot))
+
+	return snapshot, nil
+}
+
+// Matches returns the rules of the raffle in channel if the user with the
+// twitch ID userID can still enter it and text is its keyword. The user is
+// marked as being checked until Enter, Reject or Release is called so the
+// rules are only checked once at a time.
+func (p *Raffles) Matches(channel, userID, text string) (Rules, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	running, ok := p.raffles[channel]
+	if !ok || running.Closed || running.entered[userID] || running.checking[userID] || running.ineligible[userID] {
+		return Rules{}, false
+	}
+	if !strings.EqualFold(strings.TrimSpace(text), running.Keyword) {
+		return Rules{}, false
+	}
+
+	running.checking[userID] = true
+
+	return running.Rules, true
+}
+
+// Reject ends the check of the user with the twitch ID userID in channel. The
+// user did not pass the rules and can't try to enter the raffle again.
+func (p *Raffles) Reject(channel, userID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if running, ok := p.raffles[channel]; ok {
+		delete(running.checking, userID)
+		running.ineligible[userID] = true
+	}
+}
+
+// Release ends the check of the user with the twitch ID userID in channel
+// without a result, eg. because the twitch API failed. The user can try to
+// enter the raffle again.
+func (p *Raffles) Release(channel, userID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if running, ok := p.raffles[channel]; ok {
+		delete(running.checking, userID)
+	}
+}
+
+// Enter adds entrant to the raffle in channel. It reports wheather the user
+// was added. Users can only enter once.
+func (p *Raffles) Enter(channel string, entrant Entrant) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	running, ok := p.raffles[channel]
+	if !ok || running.Closed || running.entered[entrant.UserID] {
+		return false
+	}
+
+	delete(running.checking, entrant.UserID)
+	running.entered[entrant.UserID] = true
+	running.Entrants = append(running.Entrants, entrant)
+
+	return true
+}
+
+// Draw draws up to n winners from the entrants of the raffle in channel who
+// were not drawn yet. The raffle is closed for new entrants once somebody was
+// drawn.
+func (p *Raffles) Draw(channel string, n int, now time.Time) ([]Winner, error) {
+	p.mu.Lock()
+
+	running, ok := p.raffles[channel]
+	if !ok {
+		p.mu.Unlock()
+		return nil, ErrNotRunning
+	}
+
+	var winners []Winner
+	var err error
+	for i := 0; i < n; i++ {
+		var w Winner
+		if w, err = p.draw(running, now); err != nil {
+			break
+		}
+		winners = append(winners, w)
+	}
+	if err == ErrNoEntrants && len(winners) > 0 {
+		err = nil
+	}
+
+	if len(running.Winners) == 0 {
+		p.mu.Unlock()
+		return nil, err
+	}
+	running.Closed = true
+	p.unlock(p.changed(running.snapshot()))
+
+	if err != nil {
+		return nil, err
+	}
+	return winners, nil
+}
+
+// draw draws a winner of running. mu must be held.
+func (p *Raffles) draw(running *raffle, now time.Time) (Winner, error) {
+	var left []Entrant
+	for _, entrant := range running.Entrants {
+		if !running.drawn[entrant.UserID] {
+			left = append(left, entrant)
+		}
+	}
+	if len(left) == 0 {
+		return Winner{}, ErrNoEntrants
+	}
+
+	i, err := rand.Int(p.Rand, big.NewInt(int64(len(left))))
+	if err != nil {
+		return Winner{}, err
+	}
+
+	w := Winner{Entrant: left[i.Int64()], Drawn: now}
+	running.drawn[w.UserID] = true
+	running.Winners = append(running.Winners, w)
+
+	if p.Timeout > 0 {
+		userID := w.UserID
+		running.timers[userID] = time.AfterFunc(p.Timeout, func() {
+			p.expire(running, userID)
+		})
+	}
+
+	return w, nil
+}
+
+// winner returns the index of the last time userID was drawn in running.
+func (r *raffle) winner(userID string) (int, bool) {
+	for i := len(r.Winners) - 1; i >= 0; i-- {
+		if r.Winners[i].UserID == userID {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Confirm confirms the win of the user with the twitch ID userID in channel.
+// It reports wheather the user had to confirm.
+func (p *Raffles) Confirm(channel, userID string) bool {
+	p.mu.Lock()
+
+	running, ok := p.raffles[channel]
+	if !ok {
+		p.mu.Unlock()
+		return false
+	}
+
+	i, ok := running.winner(userID)
+	if !ok || !running.Winners[i].Pending() {
+		p.mu.Unlock()
+		return false
+	}
+
+	if timer, ok := running.timers[userID]; ok {
+		timer.Stop()
+		delete(running.timers, userID)
+	}
+	running.Winners[i].Confirmed = true
+
+	snapshot := running.snapshot()
+	w := running.Winners[i]
+	p.unlock(func() {
+		if p.OnConfirm != nil {
+			p.OnConfirm(snapshot, w)
+		}
+	}, p.changed(snapshot))
+
+	return true
+}
+
+// expire draws a new winner of running because userID did not confirm in
+// time.
+func (p *Raffles) expire(running *raffle, userID string) {
+	p.mu.Lock()
+
+	if p.raffles[running.Channel] != running {
+		p.mu.Unlock()
+		return
+	}
+
+	i, ok := running.winner(userID)
+	if !ok || !running.Winners[i].Pending() {
+		p.mu.Unlock()
+		return
+	}
+
+	delete(running.timers, userID)
+	running.Winners[i].Expired = true
+	expired := running.Winners[i]
+
+	var next *Winner
+	w, err := p.draw(running, time.Now())
+	switch err {
+	case nil:
+		next = &w
+	case ErrNoEntrants:
+	default:
+		log.Error().
+			Err(err).
+			Str("channel", running.Channel).
+			Msg("Redrawing raffle winner")
+	}
+
+	snapshot := running.snapshot()
+	p.unlock(func() {
+		if p.OnExpire != nil {
+			p.OnExpire(snapshot, expired, next)
+		}
+	}, p.changed(snapshot))
+}
+
+// Current returns the raffle in channel.
+func (p *Raffles) Current(channel string) (Raffle, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	running, ok := p.raffles[channel]
+	if !ok {
+		return Raffle{}, false
+	}
+
+	return running.snapshot(), true
+}
+
+// End ends the raffle in channel. Pending winners can't confirm anymore.
+func (p *Raffles) End(channel string, now time.Time) (Raffle, bool) {
+	p.mu.Lock()
+
+	running, ok := p.raffles[channel]
+	if !ok {
+		p.mu.Unlock()
+		return Raffle{}, false
+	}
+
+	delete(p.raffles, channel)
+	running.stopTimers()
+	running.Closed = true
+	running.Ended = now
+
+	snapshot := running.snapshot()
+	p.unlock(p.changed(snapshot))
+
+	return snapshot, true
+}
+
+// Close stops all raffles without calling any callbacks.
+func (p *Raffles) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for channel, running := range p.raffles {
+		running.stopTimers()
+		delete(p.raffles, channel)
+	}
+}
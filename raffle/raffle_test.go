@@ -0,0 +1,285 @@
+package raffle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enter(p *Raffles, channel string, n int) {
+	for i := 1; i <= n; i++ {
+		p.Enter(channel, Entrant{UserID: fmt.Sprint(i), UserName: fmt.Sprintf("user%d", i)})
+	}
+}
+
+func TestRaffles(t *testing.T) {
+	p := New(0)
+	var changes []Raffle
+	p.OnChange = func(r Raffle) { changes = append(changes, r) }
+
+	_, err := p.Start("chronophylos", "someone", "!join", Rules{SubOnly: true}, time.Now())
+	require.NoError(t, err)
+	_, err = p.Start("chronophylos", "someone", "!join", Rules{}, time.Now())
+	assert.Equal(t, ErrRunning, err)
+
+	rules, ok := p.Matches("chronophylos", "1", " !JOIN ")
+	assert.True(t, ok)
+	assert.True(t, rules.SubOnly)
+	_, ok = p.Matches("chronophylos", "1", "!join me")
+	assert.False(t, ok)
+	_, ok = p.Matches("someone", "1", "!join")
+	assert.False(t, ok)
+
+	// Drawing from an empty raffle keeps it open
+	_, err = p.Draw("chronophylos", 1, time.Now())
+	assert.Equal(t, ErrNoEntrants, err)
+	r, ok := p.Current("chronophylos")
+	require.True(t, ok)
+	assert.False(t, r.Closed)
+
+	enter(p, "chronophylos", 3)
+	assert.False(t, p.Enter("chronophylos", Entrant{UserID: "1"}), "entering twice")
+	_, ok = p.Matches("chronophylos", "1", "!join")
+	assert.False(t, ok, "already entered")
+
+	winners, err := p.Draw("chronophylos", 2, time.Now())
+	require.NoError(t, err)
+	require.Len(t, winners, 2)
+	assert.NotEqual(t, winners[0].UserID, winners[1].UserID)
+
+	assert.False(t, p.Enter("chronophylos", Entrant{UserID: "4"}), "entering after the draw")
+	_, ok = p.Matches("chronophylos", "4", "!join")
+	assert.False(t, ok)
+
+	winners, err = p.Draw("chronophylos", 5, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, winners, 1, "only one entrant is left")
+	_, err = p.Draw("chronophylos", 1, time.Now())
+	assert.Equal(t, ErrNoEntrants, err)
+
+	r, ok = p.End("chronophylos", time.Now())
+	require.True(t, ok)
+	assert.Len(t, r.Entrants, 3)
+	assert.Len(t, r.Winners, 3)
+	_, ok = p.End("chronophylos", time.Now())
+	assert.False(t, ok)
+	_, err = p.Draw("chronophylos", 1, time.Now())
+	assert.Equal(t, ErrNotRunning, err)
+
+	require.Len(t, changes, 5)
+	assert.False(t, changes[0].Closed)
+	assert.False(t, changes[len(changes)-1].Ended.IsZero())
+}
+
+func TestRafflesChecking(t *testing.T) {
+	p := New(0)
+
+	_, err := p.Start("chronophylos", "someone", "!join", Rules{FollowerOnly: true}, time.Now())
+	require.NoError(t, err)
+
+	_, ok := p.Matches("chronophylos", "1", "!join")
+	assert.True(t, ok)
+	_, ok = p.Matches("chronophylos", "1", "!join")
+	assert.False(t, ok, "already being checked")
+
+	// Failed checks can be tried again
+	p.Release("chronophylos", "1")
+	_, ok = p.Matches("chronophylos", "1", "!join")
+	assert.True(t, ok)
+
+	// Users who are not eligible are not checked again
+	p.Reject("chronophylos", "1")
+	_, ok = p.Matches("chronophylos", "1", "!join")
+	assert.False(t, ok)
+
+	_, ok = p.Matches("chronophylos", "2", "!join")
+	assert.True(t, ok)
+	assert.True(t, p.Enter("chronophylos", Entrant{UserID: "2"}))
+	_, ok = p.Matches("chronophylos", "2", "!join")
+	assert.False(t, ok, "already entered")
+}
+
+func TestRafflesRand(t *testing.T) {
+	p := New(0)
+	p.Rand = errReader{}
+
+	_, err := p.Start("chronophylos", "someone", "!join", Rules{}, time.Now())
+	require.NoError(t, err)
+	enter(p, "chronophylos", 3)
+
+	_, err = p.Draw("chronophylos", 1, time.Now())
+	assert.Error(t, err)
+
+	// rand.Int reads big endian bytes and masks them to the bit length of 3
+	p.Rand = bytes.NewReader([]byte{2})
+	winners, err := p.Draw("chronophylos", 1, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "3", winners[0].UserID)
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("no randomness")
+}
+
+func TestRafflesConfirm(t *testing.T) {
+	p := New(50 * time.Millisecond)
+	confirmed := make(chan Winner, 1)
+	expired := make(chan *Winner, 2)
+	p.OnConfirm = func(r Raffle, w Winner) { confirmed <- w }
+	p.OnExpire = func(r Raffle, w Winner, next *Winner) {
+		assert.True(t, w.Expired)
+		expired <- next
+	}
+
+	_, err := p.Start("chronophylos", "someone", "!join", Rules{}, time.Now())
+	require.NoError(t, err)
+	enter(p, "chronophylos", 2)
+
+	winners, err := p.Draw("chronophylos", 1, time.Now())
+	require.NoError(t, err)
+	first := winners[0]
+
+	var next *Winner
+	select {
+	case next = <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("winner did not expire")
+	}
+	require.NotNil(t, next)
+	assert.NotEqual(t, first.UserID, next.UserID)
+
+	assert.False(t, p.Confirm("chronophylos", first.UserID), "expired winners can't confirm")
+	assert.True(t, p.Confirm("chronophylos", next.UserID))
+	assert.False(t, p.Confirm("chronophylos", next.UserID), "confirming twice")
+
+	select {
+	case w := <-confirmed:
+		assert.True(t, w.Confirmed)
+		assert.Equal(t, next.UserID, w.UserID)
+	case <-time.After(time.Second):
+		t.Fatal("winner did not confirm")
+	}
+
+	r, ok := p.Current("chronophylos")
+	require.True(t, ok)
+	require.Len(t, r.Winners, 2)
+	assert.True(t, r.Winners[0].Expired)
+	assert.True(t, r.Winners[1].Confirmed)
+
+	// A redraw with nobody left
+	_, err = p.Start("someone", "someone", "!join", Rules{}, time.Now())
+	require.NoError(t, err)
+	enter(p, "someone", 1)
+	_, err = p.Draw("someone", 1, time.Now())
+	require.NoError(t, err)
+
+	select {
+	case next = <-expired:
+		assert.Nil(t, next)
+	case <-time.After(time.Second):
+		t.Fatal("winner did not expire")
+	}
+
+	p.Close()
+}
+
+type fakeChecker struct {
+	created time.Time
+	follows bool
+}
+
+func (c fakeChecker) CreatedAt(string) (time.Time, error)  { return c.created, nil }
+func (c fakeChecker) Follows(string, string) (bool, error) { return c.follows, nil }
+
+func TestEligible(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := fakeChecker{created: now.Add(-30 * 24 * time.Hour), follows: true}
+	young := fakeChecker{created: now.Add(-time.Hour)}
+
+	var tests = []struct {
+		rules    Rules
+		checker  Checker
+		sub      bool
+		eligible bool
+	}{
+		{Rules{}, nil, false, true},
+		{Rules{SubOnly: true}, nil, false, false},
+		{Rules{SubOnly: true}, nil, true, true},
+		{Rules{FollowerOnly: true}, old, false, true},
+		{Rules{FollowerOnly: true}, young, false, false},
+		{Rules{MinAccountAge: 7 * 24 * time.Hour}, old, false, true},
+		{Rules{MinAccountAge: 7 * 24 * time.Hour}, young, false, false},
+		{Rules{SubOnly: true, MinAccountAge: time.Minute}, young, true, true},
+	}
+
+	for _, test := range tests {
+		eligible, err := test.rules.Eligible(test.checker, Candidate{UserID: "1", ChannelID: "2", Subscriber: test.sub}, now)
+		require.NoError(t, err)
+		assert.Equal(t, test.eligible, eligible, "%+v", test.rules)
+	}
+}
+
+func TestHelix(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "client", r.Header.Get("Client-ID"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/users":
+			if r.URL.Query().Get("id") != "1" {
+				fmt.Fprint(w, `{"data":[]}`)
+				return
+			}
+			fmt.Fprint(w, `{"data":[{"id":"1","login":"someone","created_at":"2016-12-14T20:32:28Z"}]}`)
+		case "/channels/followers":
+			switch r.URL.Query().Get("broadcaster_id") {
+			case "2":
+				if r.URL.Query().Get("user_id") == "1" {
+					fmt.Fprint(w, `{"total":5,"data":[{"user_id":"1","user_login":"someone"}]}`)
+					return
+				}
+				fmt.Fprint(w, `{"total":5,"data":[]}`)
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error":"Unauthorized","status":401,"message":"Missing scope: moderator:read:followers"}`)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	h := NewHelix("client", func() string { return "token" })
+	h.BaseURL = server.URL
+
+	created, err := h.CreatedAt("1")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2016, 12, 14, 20, 32, 28, 0, time.UTC), created)
+	_, err = h.CreatedAt("1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests, "account ages are cached")
+
+	_, err = h.CreatedAt("3")
+	assert.Equal(t, ErrUnknownUser, err)
+
+	follows, err := h.Follows("1", "2")
+	require.NoError(t, err)
+	assert.True(t, follows)
+	follows, err = h.Follows("3", "2")
+	require.NoError(t, err)
+	assert.False(t, follows)
+
+	_, err = h.Follows("1", "4")
+	assert.True(t, errors.Is(err, ErrMissingFollowerScope), "%v", err)
+}
@@ -0,0 +1,65 @@
+package raffle
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rules decide who can enter a raffle.
+type Rules struct {
+	SubOnly      bool
+	FollowerOnly bool
+	// MinAccountAge is how old the twitch account of an entrant must be.
+	MinAccountAge time.Duration
+}
+
+// NeedsTwitch reports wheather checking the rules needs the twitch API.
+func (r Rules) NeedsTwitch() bool {
+	return r.FollowerOnly || r.MinAccountAge > 0
+}
+
+// Checker looks up users for the rules that need the twitch API.
+type Checker interface {
+	// CreatedAt returns when the account with the twitch ID userID was
+	// created.
+	CreatedAt(userID string) (time.Time, error)
+	// Follows reports wheather userID follows channelID.
+	Follows(userID, channelID string) (bool, error)
+}
+
+// Candidate is a user who wants to enter a raffle.
+type Candidate struct {
+	UserID     string
+	ChannelID  string
+	Subscriber bool
+}
+
+// Eligible reports wheather c can enter a raffle with the rules r. checker
+// may be nil if r does not need the twitch API.
+func (r Rules) Eligible(checker Checker, c Candidate, now time.Time) (bool, error) {
+	if r.SubOnly && !c.Subscriber {
+		return false, nil
+	}
+
+	if r.FollowerOnly {
+		follows, err := checker.Follows(c.UserID, c.ChannelID)
+		if err != nil {
+			return false, fmt.Errorf("checking follow: %v", err)
+		}
+		if !follows {
+			return false, nil
+		}
+	}
+
+	if r.MinAccountAge > 0 {
+		created, err := checker.CreatedAt(c.UserID)
+		if err != nil {
+			return false, fmt.Errorf("getting account age: %v", err)
+		}
+		if now.Sub(created) < r.MinAccountAge {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
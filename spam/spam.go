@@ -0,0 +1,181 @@
+// Package spam detects links, caps, symbol and emote floods and repeated
+// messages in chat.
+package spam
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/chronophylos/chb3/filter"
+)
+
+// Names of the checks.
+const (
+	Links   = "links"
+	Caps    = "caps"
+	Symbols = "symbols"
+	Emotes  = "emotes"
+	Repeats = "repeats"
+)
+
+// Checks are the names of all checks in the order they are run.
+var Checks = []string{Links, Caps, Symbols, Emotes, Repeats}
+
+// Message is a chat message to check.
+type Message struct {
+	Channel string
+	UserID  string
+	Text    string
+	// Emotes is the number of emotes in Text.
+	Emotes int
+	Time   time.Time
+}
+
+// Detector detects spam. Its zero value is not usable, use NewDetector.
+type Detector struct {
+	// MinLength is the number of characters a message needs before caps and
+	// symbols are checked.
+	MinLength int
+	// MaxCaps is the maximum ratio of uppercase letters to all letters.
+	MaxCaps float64
+	// MaxSymbols is the maximum ratio of symbols to all characters.
+	MaxSymbols float64
+	// MaxEmotes is the maximum number of emotes in a message.
+	MaxEmotes int
+	// MaxRepeats is how often a user may send the same message within
+	// RepeatWindow.
+	MaxRepeats   int
+	RepeatWindow time.Duration
+
+	mu         sync.Mutex
+	recent     map[recentKey][]recentMessage
+	lastForget time.Time
+}
+
+type recentKey struct {
+	channel string
+	userID  string
+}
+
+type recentMessage struct {
+	text string
+	time time.Time
+}
+
+// NewDetector creates a Detector with reasonable defaults.
+func NewDetector() *Detector {
+	return &Detector{
+		MinLength:    15,
+		MaxCaps:      0.7,
+		MaxSymbols:   0.5,
+		MaxEmotes:    15,
+		MaxRepeats:   2,
+		RepeatWindow: 30 * time.Second,
+		recent:       map[recentKey][]recentMessage{},
+	}
+}
+
+// Check returns the name of the first check msg fails. Checks for which
+// enabled returns false are skipped.
+func (d *Detector) Check(msg Message, enabled func(check string) bool) (string, bool) {
+	// Repeats are recorded even if the check is disabled so turning it on
+	// works right away.
+	repeated := d.repeated(msg)
+
+	for _, check := range Checks {
+		if !enabled(check) {
+			continue
+		}
+
+		var failed bool
+		switch check {
+		case Links:
+			failed = len(filter.Links(msg.Text)) > 0
+		case Caps:
+			failed = d.caps(msg.Text)
+		case Symbols:
+			failed = d.symbols(msg.Text)
+		case Emotes:
+			failed = msg.Emotes > d.MaxEmotes
+		case Repeats:
+			failed = repeated
+		}
+
+		if failed {
+			return check, true
+		}
+	}
+
+	return "", false
+}
+
+func (d *Detector) caps(text string) bool {
+	var letters, upper int
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+
+	return letters >= d.MinLength && float64(upper)/float64(letters) > d.MaxCaps
+}
+
+func (d *Detector) symbols(text string) bool {
+	var chars, symbols int
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		chars++
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			symbols++
+		}
+	}
+
+	return chars >= d.MinLength && float64(symbols)/float64(chars) > d.MaxSymbols
+}
+
+// repeated records msg and reports wheather the user sent it more than
+// MaxRepeats times within RepeatWindow.
+func (d *Detector) repeated(msg Message) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := recentKey{msg.Channel, msg.UserID}
+	text := strings.Join(strings.Fields(filter.Normalize(msg.Text)), " ")
+
+	var recent []recentMessage
+	count := 1
+	for _, m := range d.recent[key] {
+		if msg.Time.Sub(m.time) > d.RepeatWindow {
+			continue
+		}
+		recent = append(recent, m)
+		if m.text == text {
+			count++
+		}
+	}
+	d.recent[key] = append(recent, recentMessage{text: text, time: msg.Time})
+
+	if msg.Time.Sub(d.lastForget) > d.RepeatWindow {
+		d.forget(msg.Time)
+	}
+
+	return count > d.MaxRepeats
+}
+
+// forget removes users without recent messages so users who left don't use
+// memory forever. d.mu must be locked.
+func (d *Detector) forget(now time.Time) {
+	for key, messages := range d.recent {
+		if now.Sub(messages[len(messages)-1].time) > d.RepeatWindow {
+			delete(d.recent, key)
+		}
+	}
+	d.lastForget = now
+}
@@ -0,0 +1,67 @@
+package spam
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func all(string) bool { return true }
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+
+	var tests = []struct {
+		name  string
+		msg   Message
+		check string
+		found bool
+	}{
+		{"normal", Message{Text: "hello there, how are you doing?"}, "", false},
+		{"link", Message{Text: "check out example.com/free"}, Links, true},
+		{"caps", Message{Text: "WHY IS EVERYONE SO LOUD TODAY"}, Caps, true},
+		{"short caps", Message{Text: "LUL"}, "", false},
+		{"symbols", Message{Text: "!!!!!!!!!!!!!!!!!!!!??????"}, Symbols, true},
+		{"emotes", Message{Text: "Kappa", Emotes: 20}, Emotes, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDetector()
+			test.msg.Time = now
+			check, found := d.Check(test.msg, all)
+			assert.Equal(t, test.found, found)
+			assert.Equal(t, test.check, check)
+		})
+	}
+}
+
+func TestCheckDisabled(t *testing.T) {
+	d := NewDetector()
+	_, found := d.Check(Message{Text: "check out example.com/free"}, func(check string) bool {
+		return check != Links
+	})
+	assert.False(t, found)
+}
+
+func TestCheckRepeats(t *testing.T) {
+	d := NewDetector()
+	now := time.Now()
+	msg := Message{Channel: "chronophylos", UserID: "1", Text: "buy followers"}
+
+	for i := 0; i < d.MaxRepeats; i++ {
+		msg.Time = now.Add(time.Duration(i) * time.Second)
+		_, found := d.Check(msg, all)
+		assert.False(t, found)
+	}
+
+	msg.Time = now.Add(10 * time.Second)
+	check, found := d.Check(msg, all)
+	assert.True(t, found)
+	assert.Equal(t, Repeats, check)
+
+	msg.Time = now.Add(time.Hour)
+	_, found = d.Check(msg, all)
+	assert.False(t, found, "outside of window")
+}
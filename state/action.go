@@ -0,0 +1,74 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ActionState stores if an action was enabled or disabled at runtime.
+type ActionState struct {
+	Name string
+
+	// Enabled overrides the enable state in all channels if set.
+	Enabled *bool
+	// Channels overrides the enable state per channel.
+	Channels map[string]bool
+}
+
+// GetActionStates returns the enable state of all actions that were changed
+// at runtime.
+func (c *Client) GetActionStates() ([]ActionState, error) {
+	defer metrics.ObserveState("GetActionStates")()
+
+	states := []ActionState{}
+
+	col := c.mongo.Database("chb3").Collection("actions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cur, err := col.Find(ctx, bson.D{})
+	if err != nil {
+		return states, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &states)
+
+	return states, err
+}
+
+// SetActionEnabled enables or disables the action name in channel. If channel
+// is empty the action is enabled or disabled in all channels instead.
+func (c *Client) SetActionEnabled(name, channel string, enabled bool) error {
+	defer metrics.ObserveState("SetActionEnabled")()
+
+	col := c.mongo.Database("chb3").Collection("actions")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "name", Value: name}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "channels." + channel, Value: enabled},
+		}},
+	}
+	if channel == "" {
+		// Overriding all channels removes the overrides of single channels.
+		update = bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "enabled", Value: enabled},
+			}},
+			{Key: "$unset", Value: bson.D{
+				{Key: "channels", Value: ""},
+			}},
+		}
+	}
+	opts := options.Update().SetUpsert(true)
+	_, err := col.UpdateOne(ctx, filter, update, opts)
+
+	return err
+}
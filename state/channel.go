@@ -6,4 +6,33 @@ type Channel struct {
 	Joined   bool
 	Sleeping bool
 	Lurking  bool
+
+	// Language is the language code used for replies in this channel.
+	Language string
+
+	// Prefix is the command prefix used in this channel.
+	Prefix string
+
+	// Moderation are the settings of the spam moderation.
+	Moderation Moderation
+
+	// Logging is true if messages in this channel are logged.
+	Logging bool
+}
+
+// Moderation configures the spam moderation of a channel.
+type Moderation struct {
+	Enabled bool
+	// Disabled are the names of checks that are turned off, eg. caps.
+	Disabled []string
+}
+
+// IsDisabled reports wheather the check named check is turned off.
+func (m Moderation) IsDisabled(check string) bool {
+	for _, c := range m.Disabled {
+		if c == check {
+			return true
+		}
+	}
+	return false
 }
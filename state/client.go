@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/chronophylos/chb3/metrics"
 	"github.com/gempir/go-twitch-irc/v2"
 
 	"github.com/rs/zerolog/log"
@@ -41,9 +42,23 @@ func NewClient(uri string) (*Client, error) {
 	return &Client{mongo: client, upsert: &upsert}, nil
 }
 
+// Disconnect closes the connection to the database.
+func (c *Client) Disconnect(ctx context.Context) error {
+	return c.mongo.Disconnect(ctx)
+}
+
+// Ping checks if the database is still reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	defer metrics.ObserveState("Ping")()
+
+	return c.mongo.Ping(ctx, readpref.Primary())
+}
+
 // BumpUser makes sure the twitch user u exists in the database and creates it
 // if needed. Either way it sets lastseen to t.
 func (c *Client) BumpUser(u twitch.User, t time.Time) (*User, error) {
+	defer metrics.ObserveState("BumpUser")()
+
 	var user *User
 
 	col := c.mongo.Database("chb3").Collection("users")
@@ -92,6 +107,8 @@ func (c *Client) BumpUser(u twitch.User, t time.Time) (*User, error) {
 
 // GetUserByID gets the user with id id.
 func (c *Client) GetUserByID(id string) (User, error) {
+	defer metrics.ObserveState("GetUserByID")()
+
 	var user User
 
 	col := c.mongo.Database("chb3").Collection("users")
@@ -106,6 +123,8 @@ func (c *Client) GetUserByID(id string) (User, error) {
 
 // GetUserByName gets the user with name name.
 func (c *Client) GetUserByName(name string) (User, error) {
+	defer metrics.ObserveState("GetUserByName")()
+
 	var user User
 
 	col := c.mongo.Database("chb3").Collection("users")
@@ -120,6 +139,8 @@ func (c *Client) GetUserByName(name string) (User, error) {
 
 // UpdateUser updates the user user in the mongo database.
 func (c *Client) UpdateUser(user User) error {
+	defer metrics.ObserveState("UpdateUser")()
+
 	col := c.mongo.Database("chb3").Collection("users")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -139,6 +160,8 @@ func (c *Client) UpdateUser(user User) error {
 
 // IsTimedout checks if a user is timed out.
 func (c *Client) IsTimedout(id string, now time.Time) (bool, error) {
+	defer metrics.ObserveState("IsTimedout")()
+
 	user, err := c.GetUserByID(id)
 	if err != nil {
 		return false, err
@@ -148,6 +171,8 @@ func (c *Client) IsTimedout(id string, now time.Time) (bool, error) {
 
 // SetSleeping sets sleeping.
 func (c *Client) SetSleeping(channelName string, sleeping bool) error {
+	defer metrics.ObserveState("SetSleeping")()
+
 	col := c.mongo.Database("chb3").Collection("channels")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -167,8 +192,174 @@ func (c *Client) SetSleeping(channelName string, sleeping bool) error {
 	return nil
 }
 
+// GetChannel gets the channel with name channelName. If the channel is not
+// in the database an empty Channel with only its name set is returned.
+func (c *Client) GetChannel(channelName string) (Channel, error) {
+	defer metrics.ObserveState("GetChannel")()
+
+	channel := Channel{Name: channelName}
+
+	col := c.mongo.Database("chb3").Collection("channels")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "name", Value: channelName}}
+	err := col.FindOne(ctx, filter).Decode(&channel)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return channel, nil
+		}
+		return channel, err
+	}
+
+	return channel, nil
+}
+
+// SetChannelLanguage sets the language of a channel.
+func (c *Client) SetChannelLanguage(channelName, language string) error {
+	defer metrics.ObserveState("SetChannelLanguage")()
+
+	col := c.mongo.Database("chb3").Collection("channels")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "name", Value: channelName}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "language", Value: language},
+		}},
+	}
+	opts := options.FindOneAndUpdate()
+	opts.Upsert = c.upsert
+	if err := col.FindOneAndUpdate(ctx, filter, update, opts).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetChannelPrefix sets the command prefix of a channel.
+func (c *Client) SetChannelPrefix(channelName, prefix string) error {
+	defer metrics.ObserveState("SetChannelPrefix")()
+
+	col := c.mongo.Database("chb3").Collection("channels")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "name", Value: channelName}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "prefix", Value: prefix},
+		}},
+	}
+	opts := options.FindOneAndUpdate()
+	opts.Upsert = c.upsert
+	if err := col.FindOneAndUpdate(ctx, filter, update, opts).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetChannelModeration sets the spam moderation settings of the channel.
+func (c *Client) SetChannelModeration(channelName string, moderation Moderation) error {
+	defer metrics.ObserveState("SetChannelModeration")()
+
+	col := c.mongo.Database("chb3").Collection("channels")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "name", Value: channelName}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "moderation", Value: moderation},
+		}},
+	}
+	opts := options.FindOneAndUpdate()
+	opts.Upsert = c.upsert
+	if err := col.FindOneAndUpdate(ctx, filter, update, opts).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetChannelLogging sets wheather messages in the channel named channelName
+// are logged.
+func (c *Client) SetChannelLogging(channelName string, logging bool) error {
+	defer metrics.ObserveState("SetChannelLogging")()
+
+	col := c.mongo.Database("chb3").Collection("channels")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "name", Value: channelName}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "logging", Value: logging},
+		}},
+	}
+	opts := options.FindOneAndUpdate()
+	opts.Upsert = c.upsert
+	if err := col.FindOneAndUpdate(ctx, filter, update, opts).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetUserLogOptOut sets wheather the messages of the user with id id must not
+// be logged.
+func (c *Client) SetUserLogOptOut(id string, optOut bool) error {
+	defer metrics.ObserveState("SetUserLogOptOut")()
+
+	col := c.mongo.Database("chb3").Collection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "id", Value: id}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "logoptout", Value: optOut},
+		}},
+	}
+	return col.FindOneAndUpdate(ctx, filter, update).Err()
+}
+
+// SetUserLanguage sets the language override of the user with id id. An empty
+// language removes the override.
+func (c *Client) SetUserLanguage(id, language string) error {
+	defer metrics.ObserveState("SetUserLanguage")()
+
+	col := c.mongo.Database("chb3").Collection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "id", Value: id}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "language", Value: language},
+		}},
+	}
+	return col.FindOneAndUpdate(ctx, filter, update).Err()
+}
+
 // IsSleeping checks if a channels is sleeping.
 func (c *Client) IsSleeping(channelName string) (bool, error) {
+	defer metrics.ObserveState("IsSleeping")()
+
 	var channel Channel
 
 	col := c.mongo.Database("chb3").Collection("channels")
@@ -189,6 +380,8 @@ func (c *Client) IsSleeping(channelName string) (bool, error) {
 
 // IsLurking return true if the bot is just lurking in a channel.
 func (c *Client) IsLurking(channelName string) (bool, error) {
+	defer metrics.ObserveState("IsLurking")()
+
 	var channel Channel
 
 	col := c.mongo.Database("chb3").Collection("channels")
@@ -209,6 +402,8 @@ func (c *Client) IsLurking(channelName string) (bool, error) {
 
 // SetLurking sets lurking.
 func (c *Client) SetLurking(channelName string, lurking bool) error {
+	defer metrics.ObserveState("SetLurking")()
+
 	col := c.mongo.Database("chb3").Collection("channels")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -228,6 +423,8 @@ func (c *Client) SetLurking(channelName string, lurking bool) error {
 
 // GetJoinedChannels returns all currentyl joined channels.
 func (c *Client) GetJoinedChannels() ([]string, error) {
+	defer metrics.ObserveState("GetJoinedChannels")()
+
 	channels := []string{}
 
 	col := c.mongo.Database("chb3").Collection("channels")
@@ -262,8 +459,31 @@ func (c *Client) GetJoinedChannels() ([]string, error) {
 	return channels, nil
 }
 
+// GetChannels returns all channels the bot knows about.
+func (c *Client) GetChannels() ([]Channel, error) {
+	defer metrics.ObserveState("GetChannels")()
+
+	channels := []Channel{}
+
+	col := c.mongo.Database("chb3").Collection("channels")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cur, err := col.Find(ctx, bson.D{})
+	if err != nil {
+		return channels, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &channels)
+
+	return channels, err
+}
+
 // JoinChannel sets joined.
 func (c *Client) JoinChannel(channelName string, joined bool) error {
+	defer metrics.ObserveState("JoinChannel")()
+
 	col := c.mongo.Database("chb3").Collection("channels")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -284,6 +504,8 @@ func (c *Client) JoinChannel(channelName string, joined bool) error {
 
 // IsChannelJoined check if a channel is joined.
 func (c *Client) IsChannelJoined(channelName string) (bool, error) {
+	defer metrics.ObserveState("IsChannelJoined")()
+
 	var channel Channel
 
 	col := c.mongo.Database("chb3").Collection("channels")
@@ -301,6 +523,8 @@ func (c *Client) IsChannelJoined(channelName string) (bool, error) {
 
 // AddVoicemail adds a voicemail to a user.
 func (c *Client) AddVoicemail(username, channel, creator, message string, created time.Time) error {
+	defer metrics.ObserveState("AddVoicemail")()
+
 	col := c.mongo.Database("chb3").Collection("users")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -332,6 +556,8 @@ func (c *Client) AddVoicemail(username, channel, creator, message string, create
 
 // CheckForVoicemails pops all voicemails a user has
 func (c *Client) CheckForVoicemails(name string) ([]*Voicemail, error) {
+	defer metrics.ObserveState("CheckForVoicemails")()
+
 	var voicemails []*Voicemail
 	var user User
 
@@ -358,6 +584,8 @@ func (c *Client) CheckForVoicemails(name string) ([]*Voicemail, error) {
 }
 
 func (c *Client) Patsch(id string, now time.Time) error {
+	defer metrics.ObserveState("Patsch")()
+
 	col := c.mongo.Database("chb3").Collection("users")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FilterRule is a word or regular expression that is filtered in a channel.
+type FilterRule struct {
+	Channel string
+	Pattern string
+	Regex   bool
+	// Action is one of ignore, delete, timeout or ban.
+	Action string
+	// Duration is the length of a timeout.
+	Duration time.Duration
+	// By is the name of the user who added the rule.
+	By      string
+	Created time.Time
+}
+
+// GetFilterRules returns the filter rules of all channels.
+func (c *Client) GetFilterRules() ([]FilterRule, error) {
+	defer metrics.ObserveState("GetFilterRules")()
+
+	rules := []FilterRule{}
+
+	col := c.mongo.Database("chb3").Collection("filters")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cur, err := col.Find(ctx, bson.D{})
+	if err != nil {
+		return rules, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &rules)
+
+	return rules, err
+}
+
+// AddFilterRule stores rule and replaces a rule with the same pattern in the
+// same channel.
+func (c *Client) AddFilterRule(rule FilterRule) error {
+	defer metrics.ObserveState("AddFilterRule")()
+
+	col := c.mongo.Database("chb3").Collection("filters")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: rule.Channel},
+		{Key: "pattern", Value: rule.Pattern},
+		{Key: "regex", Value: rule.Regex},
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err := col.ReplaceOne(ctx, filter, rule, opts)
+
+	return err
+}
+
+// RemoveFilterRule removes the rule with pattern from channel. ok is false if
+// there was no such rule.
+func (c *Client) RemoveFilterRule(channel, pattern string, regex bool) (ok bool, err error) {
+	defer metrics.ObserveState("RemoveFilterRule")()
+
+	col := c.mongo.Database("chb3").Collection("filters")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "pattern", Value: pattern},
+		{Key: "regex", Value: regex},
+	}
+	result, err := col.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
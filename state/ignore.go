@@ -0,0 +1,97 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ignore makes the bot ignore all messages of a user.
+type Ignore struct {
+	UserID   string
+	UserName string
+	// Channel is the channel the user is ignored in. The user is ignored
+	// everywhere if it is empty.
+	Channel string
+	Reason  string
+	// By is the name of the user who added the ignore.
+	By      string
+	Created time.Time
+	// Expires is zero if the ignore never expires.
+	Expires time.Time
+}
+
+// Expired reports wheather the ignore expired at t.
+func (i Ignore) Expired(t time.Time) bool {
+	return !i.Expires.IsZero() && !t.Before(i.Expires)
+}
+
+// GetIgnores returns all ignores that did not expire yet.
+func (c *Client) GetIgnores() ([]Ignore, error) {
+	defer metrics.ObserveState("GetIgnores")()
+
+	ignores := []Ignore{}
+
+	col := c.mongo.Database("chb3").Collection("ignores")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "expires", Value: time.Time{}}},
+			bson.D{{Key: "expires", Value: bson.D{{Key: "$gt", Value: time.Now()}}}},
+		}},
+	}
+	cur, err := col.Find(ctx, filter)
+	if err != nil {
+		return ignores, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &ignores)
+
+	return ignores, err
+}
+
+// AddIgnore stores ignore and replaces an ignore of the same user in the same
+// channel.
+func (c *Client) AddIgnore(ignore Ignore) error {
+	defer metrics.ObserveState("AddIgnore")()
+
+	col := c.mongo.Database("chb3").Collection("ignores")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "userid", Value: ignore.UserID},
+		{Key: "channel", Value: ignore.Channel},
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err := col.ReplaceOne(ctx, filter, ignore, opts)
+
+	return err
+}
+
+// RemoveIgnore removes the ignore of the user with the twitch ID userID in
+// channel. ok is false if there was no such ignore.
+func (c *Client) RemoveIgnore(userID, channel string) (ok bool, err error) {
+	defer metrics.ObserveState("RemoveIgnore")()
+
+	col := c.mongo.Database("chb3").Collection("ignores")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "userid", Value: userID},
+		{Key: "channel", Value: channel},
+	}
+	result, err := col.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
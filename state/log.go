@@ -0,0 +1,324 @@
+package state
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LogLine is a logged chat message.
+type LogLine struct {
+	Channel  string
+	UserID   string
+	UserName string
+	Text     string
+	Tags     map[string]string
+	// Raw is the IRC message as received from twitch.
+	Raw  string
+	Time time.Time
+}
+
+// logTTLIndex is the name of the index expiring old log lines.
+const logTTLIndex = "time_ttl"
+
+// EnsureLogIndexes creates the indexes of the log collection. Log lines older
+// than retention are removed by mongo.
+func (c *Client) EnsureLogIndexes(retention time.Duration) error {
+	defer metrics.ObserveState("EnsureLogIndexes")()
+
+	col := c.mongo.Database("chb3").Collection("logs")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ttl := mongo.IndexModel{
+		Keys: bson.D{{Key: "time", Value: 1}},
+		Options: options.Index().
+			SetName(logTTLIndex).
+			SetExpireAfterSeconds(int32(retention.Seconds())),
+	}
+	if _, err := col.Indexes().CreateOne(ctx, ttl); err != nil {
+		// The retention changed. Indexes can't be changed so recreate it.
+		if cmdErr, ok := err.(mongo.CommandError); !ok || cmdErr.Name != "IndexOptionsConflict" {
+			return err
+		}
+		if _, err := col.Indexes().DropOne(ctx, logTTLIndex); err != nil {
+			return err
+		}
+		if _, err := col.Indexes().CreateOne(ctx, ttl); err != nil {
+			return err
+		}
+	}
+
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "channel", Value: 1},
+			{Key: "userid", Value: 1},
+			{Key: "time", Value: -1},
+		},
+	})
+
+	return err
+}
+
+// GetLoggedChannels returns the names of all channels with logging turned on.
+func (c *Client) GetLoggedChannels() ([]string, error) {
+	defer metrics.ObserveState("GetLoggedChannels")()
+
+	channels := []string{}
+
+	col := c.mongo.Database("chb3").Collection("channels")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cur, err := col.Find(ctx, bson.D{{Key: "logging", Value: true}})
+	if err != nil {
+		return channels, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var channel Channel
+		if err := cur.Decode(&channel); err != nil {
+			return channels, err
+		}
+		channels = append(channels, channel.Name)
+	}
+
+	return channels, cur.Err()
+}
+
+// GetLogOptOuts returns the twitch IDs of all users who opted out of logging.
+func (c *Client) GetLogOptOuts() ([]string, error) {
+	defer metrics.ObserveState("GetLogOptOuts")()
+
+	ids := []string{}
+
+	col := c.mongo.Database("chb3").Collection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cur, err := col.Find(ctx, bson.D{{Key: "logoptout", Value: true}})
+	if err != nil {
+		return ids, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var user User
+		if err := cur.Decode(&user); err != nil {
+			return ids, err
+		}
+		ids = append(ids, user.ID)
+	}
+
+	return ids, cur.Err()
+}
+
+// AddLogLine stores line.
+func (c *Client) AddLogLine(line LogLine) error {
+	defer metrics.ObserveState("AddLogLine")()
+
+	col := c.mongo.Database("chb3").Collection("logs")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := col.InsertOne(ctx, line)
+
+	return err
+}
+
+// GetLastLogLine returns the newest line of the user with the twitch ID
+// userID in channel. It returns nil if there is none.
+func (c *Client) GetLastLogLine(channel, userID string) (*LogLine, error) {
+	defer metrics.ObserveState("GetLastLogLine")()
+
+	var line LogLine
+
+	col := c.mongo.Database("chb3").Collection("logs")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "userid", Value: userID},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "time", Value: -1}})
+	if err := col.FindOne(ctx, filter, opts).Decode(&line); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &line, nil
+}
+
+// GetRandomLogLine returns a random line of the user with the twitch ID
+// userID in channel. It returns nil if there is none.
+func (c *Client) GetRandomLogLine(channel, userID string) (*LogLine, error) {
+	defer metrics.ObserveState("GetRandomLogLine")()
+
+	col := c.mongo.Database("chb3").Collection("logs")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "channel", Value: channel},
+			{Key: "userid", Value: userID},
+		}}},
+		{{Key: "$sample", Value: bson.D{{Key: "size", Value: 1}}}},
+	}
+	cur, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	lines := []LogLine{}
+	if err := cur.All(ctx, &lines); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	return &lines[0], nil
+}
+
+// SearchLogLines returns up to limit lines of the user with the twitch ID
+// userID in channel containing text, newest first. Case is ignored.
+func (c *Client) SearchLogLines(channel, userID, text string, limit int) ([]LogLine, error) {
+	defer metrics.ObserveState("SearchLogLines")()
+
+	lines := []LogLine{}
+
+	col := c.mongo.Database("chb3").Collection("logs")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "userid", Value: userID},
+		{Key: "text", Value: bson.D{
+			{Key: "$regex", Value: regexp.QuoteMeta(text)},
+			{Key: "$options", Value: "i"},
+		}},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "time", Value: -1}}).
+		SetLimit(int64(limit))
+	cur, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return lines, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &lines)
+
+	return lines, err
+}
+
+// GetLogLines returns the lines in channel sent at or after from and before
+// to, oldest first. If userID is not empty only lines of the user with this
+// twitch ID are returned.
+func (c *Client) GetLogLines(channel, userID string, from, to time.Time) ([]LogLine, error) {
+	defer metrics.ObserveState("GetLogLines")()
+
+	lines := []LogLine{}
+
+	col := c.mongo.Database("chb3").Collection("logs")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "time", Value: bson.D{
+			{Key: "$gte", Value: from},
+			{Key: "$lt", Value: to},
+		}},
+	}
+	if userID != "" {
+		filter = append(filter, bson.E{Key: "userid", Value: userID})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: 1}})
+	cur, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return lines, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &lines)
+
+	return lines, err
+}
+
+// GetLogDays returns the days in UTC with lines in channel, oldest first. If
+// userID is not empty only days with lines of the user with this twitch ID
+// are returned.
+func (c *Client) GetLogDays(channel, userID string) ([]time.Time, error) {
+	defer metrics.ObserveState("GetLogDays")()
+
+	days := []time.Time{}
+
+	col := c.mongo.Database("chb3").Collection("logs")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	match := bson.D{{Key: "channel", Value: channel}}
+	if userID != "" {
+		match = append(match, bson.E{Key: "userid", Value: userID})
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "$dateToString", Value: bson.D{
+					{Key: "format", Value: "%Y-%m-%d"},
+					{Key: "date", Value: "$time"},
+				}},
+			}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	cur, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return days, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var group struct {
+			ID string `bson:"_id"`
+		}
+		if err := cur.Decode(&group); err != nil {
+			return days, err
+		}
+
+		day, err := time.Parse("2006-01-02", group.ID)
+		if err != nil {
+			return days, err
+		}
+		days = append(days, day)
+	}
+
+	return days, cur.Err()
+}
+
+// RemoveLogLines removes all lines of the user with the twitch ID userID.
+func (c *Client) RemoveLogLines(userID string) error {
+	defer metrics.ObserveState("RemoveLogLines")()
+
+	col := c.mongo.Database("chb3").Collection("logs")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := col.DeleteMany(ctx, bson.D{{Key: "userid", Value: userID}})
+
+	return err
+}
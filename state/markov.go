@@ -0,0 +1,88 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MarkovChain is a part of a encoded markov chain of a channel or user. Large
+// chains are stored in several parts so they don't exceed the size limit of a
+// document.
+type MarkovChain struct {
+	Key     string
+	Part    int
+	Data    []byte
+	Updated time.Time
+}
+
+// GetMarkovChains returns all parts of the stored markov chains ordered by key
+// and part.
+func (c *Client) GetMarkovChains() ([]MarkovChain, error) {
+	defer metrics.ObserveState("GetMarkovChains")()
+
+	chains := []MarkovChain{}
+
+	col := c.mongo.Database("chb3").Collection("markov")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "key", Value: 1}, {Key: "part", Value: 1}})
+	cur, err := col.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return chains, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &chains)
+
+	return chains, err
+}
+
+// SetMarkovChain stores the parts of the chain with key and replaces its
+// previous parts.
+func (c *Client) SetMarkovChain(key string, parts [][]byte) error {
+	defer metrics.ObserveState("SetMarkovChain")()
+
+	col := c.mongo.Database("chb3").Collection("markov")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	opts := options.Replace().SetUpsert(true)
+	for i, data := range parts {
+		filter := bson.D{{Key: "key", Value: key}, {Key: "part", Value: i}}
+		chain := MarkovChain{Key: key, Part: i, Data: data, Updated: now}
+		if _, err := col.ReplaceOne(ctx, filter, chain, opts); err != nil {
+			return err
+		}
+	}
+
+	// Remove the parts left over from a larger chain and chains stored
+	// before they were split
+	_, err := col.DeleteMany(ctx, bson.D{
+		{Key: "key", Value: key},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "part", Value: bson.D{{Key: "$gte", Value: len(parts)}}}},
+			bson.D{{Key: "part", Value: bson.D{{Key: "$exists", Value: false}}}},
+		}},
+	})
+
+	return err
+}
+
+// RemoveMarkovChain removes all parts of the chain with key.
+func (c *Client) RemoveMarkovChain(key string) error {
+	defer metrics.ObserveState("RemoveMarkovChain")()
+
+	col := c.mongo.Database("chb3").Collection("markov")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := col.DeleteMany(ctx, bson.D{{Key: "key", Value: key}})
+
+	return err
+}
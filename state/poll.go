@@ -0,0 +1,55 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Poll is the result of a poll.
+type Poll struct {
+	Channel  string
+	Question string
+	Options  []string
+	// Counts are the votes per option.
+	Counts []int
+	Voters int
+	// By is the name of the user who started the poll.
+	By      string
+	Started time.Time
+	Ended   time.Time
+}
+
+// AddPoll stores the result of poll.
+func (c *Client) AddPoll(poll Poll) error {
+	defer metrics.ObserveState("AddPoll")()
+
+	col := c.mongo.Database("chb3").Collection("polls")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := col.InsertOne(ctx, poll)
+
+	return err
+}
+
+// GetLastPoll returns the result of the last poll that ended in channel. It
+// returns mongo.ErrNoDocuments if there is none.
+func (c *Client) GetLastPoll(channel string) (Poll, error) {
+	defer metrics.ObserveState("GetLastPoll")()
+
+	var poll Poll
+
+	col := c.mongo.Database("chb3").Collection("polls")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "channel", Value: channel}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "ended", Value: -1}})
+	err := col.FindOne(ctx, filter, opts).Decode(&poll)
+
+	return poll, err
+}
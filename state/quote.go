@@ -0,0 +1,190 @@
+package state
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Quote is a quote of a channel.
+type Quote struct {
+	Channel string
+	// ID is the number of the quote in its channel starting at 1.
+	ID   int
+	Text string
+	// User is the name of the user the quote is attributed to. May be empty.
+	User string
+	// By is the name and ByID the twitch ID of the user who added the quote.
+	By      string
+	ByID    string
+	Created time.Time
+}
+
+// EnsureQuoteIndexes creates the indexes of the quote collections so quotes
+// and their counters are found without scanning the collections.
+func (c *Client) EnsureQuoteIndexes() error {
+	defer metrics.ObserveState("EnsureQuoteIndexes")()
+
+	db := c.mongo.Database("chb3")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection("quotes").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "channel", Value: 1},
+			{Key: "id", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("counters").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return err
+}
+
+// AddQuote stores quote with the next ID of its channel and returns it with
+// the ID set.
+func (c *Client) AddQuote(quote Quote) (Quote, error) {
+	defer metrics.ObserveState("AddQuote")()
+
+	db := c.mongo.Database("chb3")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// IDs are counted separately so deleted IDs are not reused
+	var counter struct {
+		Seq int
+	}
+	filter := bson.D{{Key: "name", Value: "quotes/" + quote.Channel}}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: 1}}}}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+	if err := db.Collection("counters").FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter); err != nil {
+		return quote, err
+	}
+
+	quote.ID = counter.Seq
+	_, err := db.Collection("quotes").InsertOne(ctx, quote)
+
+	return quote, err
+}
+
+// GetQuote returns the quote with id in channel. It returns
+// mongo.ErrNoDocuments if there is none.
+func (c *Client) GetQuote(channel string, id int) (Quote, error) {
+	defer metrics.ObserveState("GetQuote")()
+
+	var quote Quote
+
+	col := c.mongo.Database("chb3").Collection("quotes")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "id", Value: id},
+	}
+	err := col.FindOne(ctx, filter).Decode(&quote)
+
+	return quote, err
+}
+
+// GetRandomQuote returns a random quote of channel. If user is not empty the
+// quote is attributed to user. It returns mongo.ErrNoDocuments if there is
+// none.
+func (c *Client) GetRandomQuote(channel, user string) (Quote, error) {
+	defer metrics.ObserveState("GetRandomQuote")()
+
+	col := c.mongo.Database("chb3").Collection("quotes")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	match := bson.D{{Key: "channel", Value: channel}}
+	if user != "" {
+		match = append(match, bson.E{Key: "user", Value: user})
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sample", Value: bson.D{{Key: "size", Value: 1}}}},
+	}
+	cur, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer cur.Close(ctx)
+
+	quotes := []Quote{}
+	if err := cur.All(ctx, &quotes); err != nil {
+		return Quote{}, err
+	}
+	if len(quotes) == 0 {
+		return Quote{}, mongo.ErrNoDocuments
+	}
+
+	return quotes[0], nil
+}
+
+// SearchQuotes returns up to limit quotes of channel containing text, lowest
+// ID first. Case is ignored.
+func (c *Client) SearchQuotes(channel, text string, limit int) ([]Quote, error) {
+	defer metrics.ObserveState("SearchQuotes")()
+
+	quotes := []Quote{}
+
+	col := c.mongo.Database("chb3").Collection("quotes")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "text", Value: bson.D{
+			{Key: "$regex", Value: regexp.QuoteMeta(text)},
+			{Key: "$options", Value: "i"},
+		}},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "id", Value: 1}}).
+		SetLimit(int64(limit))
+	cur, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return quotes, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &quotes)
+
+	return quotes, err
+}
+
+// RemoveQuote removes the quote with id from channel. ok is false if there
+// was no such quote.
+func (c *Client) RemoveQuote(channel string, id int) (ok bool, err error) {
+	defer metrics.ObserveState("RemoveQuote")()
+
+	col := c.mongo.Database("chb3").Collection("quotes")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "id", Value: id},
+	}
+	result, err := col.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,63 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Raffle is the audit record of a raffle.
+type Raffle struct {
+	Channel       string
+	Keyword       string
+	SubOnly       bool
+	FollowerOnly  bool
+	MinAccountAge time.Duration
+	// By is the name of the user who started the raffle.
+	By       string
+	Started  time.Time
+	Ended    time.Time
+	Entrants []RaffleEntrant
+	Winners  []RaffleWinner
+}
+
+// RaffleEntrant is a user who entered a raffle.
+type RaffleEntrant struct {
+	UserID   string
+	UserName string
+	Time     time.Time
+}
+
+// RaffleWinner is a drawn entrant.
+type RaffleWinner struct {
+	UserID    string
+	UserName  string
+	Drawn     time.Time
+	Confirmed bool
+	Expired   bool
+}
+
+// SetRaffle stores raffle. It replaces the raffle started at the same time
+// in the same channel.
+func (c *Client) SetRaffle(raffle Raffle) error {
+	defer metrics.ObserveState("SetRaffle")()
+
+	col := c.mongo.Database("chb3").Collection("raffles")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// mongo stores milliseconds so the filter would not match otherwise
+	raffle.Started = raffle.Started.Truncate(time.Millisecond)
+
+	filter := bson.D{
+		{Key: "channel", Value: raffle.Channel},
+		{Key: "started", Value: raffle.Started},
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err := col.ReplaceOne(ctx, filter, raffle, opts)
+
+	return err
+}
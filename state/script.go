@@ -0,0 +1,74 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ScriptValue is a single entry in the key-value store of a script.
+type ScriptValue struct {
+	Script string
+	Key    string
+	Value  string
+}
+
+// GetScriptValue gets the value stored under key by script. ok is false if
+// there is no such value.
+func (c *Client) GetScriptValue(script, key string) (value string, ok bool, err error) {
+	defer metrics.ObserveState("GetScriptValue")()
+
+	var v ScriptValue
+
+	col := c.mongo.Database("chb3").Collection("scriptvalues")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "script", Value: script}, {Key: "key", Value: key}}
+	if err := col.FindOne(ctx, filter).Decode(&v); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	return v.Value, true, nil
+}
+
+// SetScriptValue stores value under key for script.
+func (c *Client) SetScriptValue(script, key, value string) error {
+	defer metrics.ObserveState("SetScriptValue")()
+
+	col := c.mongo.Database("chb3").Collection("scriptvalues")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "script", Value: script}, {Key: "key", Value: key}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "value", Value: value},
+		}},
+	}
+	opts := options.Update().SetUpsert(true)
+	_, err := col.UpdateOne(ctx, filter, update, opts)
+
+	return err
+}
+
+// DeleteScriptValue removes the value stored under key by script.
+func (c *Client) DeleteScriptValue(script, key string) error {
+	defer metrics.ObserveState("DeleteScriptValue")()
+
+	col := c.mongo.Database("chb3").Collection("scriptvalues")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "script", Value: script}, {Key: "key", Value: key}}
+	_, err := col.DeleteOne(ctx, filter)
+
+	return err
+}
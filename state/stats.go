@@ -0,0 +1,334 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UserStats counts the messages of a user in a channel on a day.
+type UserStats struct {
+	Channel  string
+	UserID   string
+	UserName string
+	// Day is the start of the day in UTC.
+	Day      time.Time
+	Messages int
+}
+
+// ChannelStats counts the messages in a channel in an hour.
+type ChannelStats struct {
+	Channel string
+	// Hour is the start of the hour in UTC.
+	Hour     time.Time
+	Messages int
+}
+
+// statsBatches is how many of the last batches added to a stored count are
+// remembered. Retrying an older batch could count its messages twice.
+const statsBatches = 20
+
+// EnsureStatsIndexes creates the unique indexes of the stats collections.
+// Retried batches rely on them to not create a second count.
+func (c *Client) EnsureStatsIndexes() error {
+	defer metrics.ObserveState("EnsureStatsIndexes")()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := c.mongo.Database("chb3").Collection("userstats").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "channel", Value: 1},
+			{Key: "userid", Value: 1},
+			{Key: "day", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.mongo.Database("chb3").Collection("channelstats").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "channel", Value: 1},
+			{Key: "hour", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return err
+}
+
+// addBatch returns the update of a stored count adding messages of batch.
+// Together with notInBatch a batch is only added once so failed writes can
+// be retried.
+func addBatch(batch string, messages int) bson.D {
+	return bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "messages", Value: messages}}},
+		{Key: "$push", Value: bson.D{{Key: "batches", Value: bson.D{
+			{Key: "$each", Value: bson.A{batch}},
+			{Key: "$slice", Value: -statsBatches},
+		}}}},
+	}
+}
+
+// notInBatch matches stored counts batch was not added to yet.
+func notInBatch(batch string) bson.E {
+	return bson.E{Key: "batches", Value: bson.D{{Key: "$ne", Value: batch}}}
+}
+
+// AddUserStats adds the message counts of users to the stored counts. batch
+// identifies the counts. Adding a batch again does not change the counts it
+// was already added to. If the write fails it returns the counts that may not
+// have been added.
+func (c *Client) AddUserStats(batch string, users []UserStats) ([]UserStats, error) {
+	defer metrics.ObserveState("AddUserStats")()
+
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	col := c.mongo.Database("chb3").Collection("userstats")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := make([]mongo.WriteModel, len(users))
+	for i, stats := range users {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.D{
+				{Key: "channel", Value: stats.Channel},
+				{Key: "userid", Value: stats.UserID},
+				{Key: "day", Value: stats.Day},
+				notInBatch(batch),
+			}).
+			SetUpdate(append(addBatch(batch, stats.Messages),
+				bson.E{Key: "$set", Value: bson.D{{Key: "username", Value: stats.UserName}}},
+			)).
+			SetUpsert(true)
+	}
+
+	_, err := col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err == nil {
+		return nil, nil
+	}
+
+	indices := failedWrites(err, len(users))
+	if len(indices) == 0 {
+		return nil, nil
+	}
+	failed := make([]UserStats, len(indices))
+	for i, j := range indices {
+		failed[i] = users[j]
+	}
+	return failed, err
+}
+
+// AddChannelStats adds the message counts of channels to the stored counts
+// like AddUserStats.
+func (c *Client) AddChannelStats(batch string, channels []ChannelStats) ([]ChannelStats, error) {
+	defer metrics.ObserveState("AddChannelStats")()
+
+	if len(channels) == 0 {
+		return nil, nil
+	}
+
+	col := c.mongo.Database("chb3").Collection("channelstats")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models := make([]mongo.WriteModel, len(channels))
+	for i, stats := range channels {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.D{
+				{Key: "channel", Value: stats.Channel},
+				{Key: "hour", Value: stats.Hour},
+				notInBatch(batch),
+			}).
+			SetUpdate(addBatch(batch, stats.Messages)).
+			SetUpsert(true)
+	}
+
+	_, err := col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err == nil {
+		return nil, nil
+	}
+
+	indices := failedWrites(err, len(channels))
+	if len(indices) == 0 {
+		return nil, nil
+	}
+	failed := make([]ChannelStats, len(indices))
+	for i, j := range indices {
+		failed[i] = channels[j]
+	}
+	return failed, err
+}
+
+// duplicateKey is the code of the write error of an upsert finding a count
+// the batch was already added to.
+const duplicateKey = 11000
+
+// failedWrites returns the indices of the models of an unordered bulk write
+// of n models that may have failed with err. Only the write errors of a
+// BulkWriteException tell which models failed. For other errors all models
+// are assumed to have failed. Retrying them is safe since batches are only
+// added once.
+func failedWrites(err error, n int) []int {
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || bwe.WriteConcernError != nil {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+
+	var failed []int
+	for _, we := range bwe.WriteErrors {
+		if we.Code != duplicateKey {
+			failed = append(failed, we.Index)
+		}
+	}
+	return failed
+}
+
+// GetUserStats returns the daily message counts of the user with the twitch
+// ID userID in channel.
+func (c *Client) GetUserStats(channel, userID string) ([]UserStats, error) {
+	defer metrics.ObserveState("GetUserStats")()
+
+	stats := []UserStats{}
+
+	col := c.mongo.Database("chb3").Collection("userstats")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "userid", Value: userID},
+	}
+	cur, err := col.Find(ctx, filter)
+	if err != nil {
+		return stats, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &stats)
+
+	return stats, err
+}
+
+// GetTopChatters returns the limit users with the most messages in channel
+// since the day of since, most messages first. Day of the returned stats is
+// not set.
+func (c *Client) GetTopChatters(channel string, since time.Time, limit int) ([]UserStats, error) {
+	defer metrics.ObserveState("GetTopChatters")()
+
+	match := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "day", Value: bson.D{{Key: "$gte", Value: since}}},
+	}
+	return c.sumUserStats(match, limit)
+}
+
+// GetChatters returns the message counts of the users with the twitch IDs
+// userIDs in channel since the day of since. Users without messages are
+// left out. Day of the returned stats is not set.
+func (c *Client) GetChatters(channel string, since time.Time, userIDs []string) ([]UserStats, error) {
+	defer metrics.ObserveState("GetChatters")()
+
+	if len(userIDs) == 0 {
+		return []UserStats{}, nil
+	}
+
+	match := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "userid", Value: bson.D{{Key: "$in", Value: userIDs}}},
+		{Key: "day", Value: bson.D{{Key: "$gte", Value: since}}},
+	}
+	return c.sumUserStats(match, 0)
+}
+
+// sumUserStats sums the user stats matching match per user, most messages
+// first. All users are returned if limit is zero.
+func (c *Client) sumUserStats(match bson.D, limit int) ([]UserStats, error) {
+	stats := []UserStats{}
+
+	col := c.mongo.Database("chb3").Collection("userstats")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$userid"},
+			{Key: "channel", Value: bson.D{{Key: "$first", Value: "$channel"}}},
+			{Key: "userid", Value: bson.D{{Key: "$first", Value: "$userid"}}},
+			{Key: "username", Value: bson.D{{Key: "$last", Value: "$username"}}},
+			{Key: "messages", Value: bson.D{{Key: "$sum", Value: "$messages"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "messages", Value: -1}}}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit}})
+	}
+	cur, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return stats, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &stats)
+
+	return stats, err
+}
+
+// CountChatters returns the number of users who wrote in channel since the
+// day of since.
+func (c *Client) CountChatters(channel string, since time.Time) (int, error) {
+	defer metrics.ObserveState("CountChatters")()
+
+	col := c.mongo.Database("chb3").Collection("userstats")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "day", Value: bson.D{{Key: "$gte", Value: since}}},
+	}
+	ids, err := col.Distinct(ctx, "userid", filter)
+
+	return len(ids), err
+}
+
+// GetChannelStats returns the hourly message counts of channel since since,
+// oldest first.
+func (c *Client) GetChannelStats(channel string, since time.Time) ([]ChannelStats, error) {
+	defer metrics.ObserveState("GetChannelStats")()
+
+	stats := []ChannelStats{}
+
+	col := c.mongo.Database("chb3").Collection("channelstats")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "hour", Value: bson.D{{Key: "$gte", Value: since}}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "hour", Value: 1}})
+	cur, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return stats, err
+	}
+	defer cur.Close(ctx)
+
+	err = cur.All(ctx, &stats)
+
+	return stats, err
+}
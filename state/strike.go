@@ -0,0 +1,53 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Strikes counts how often a user violated the spam rules of a channel.
+type Strikes struct {
+	Channel string
+	UserID  string
+	Count   int
+	Last    time.Time
+}
+
+// AddStrike counts a violation of the user with the twitch ID userID in
+// channel at t and returns the number of strikes. Strikes older than expire
+// are forgotten.
+func (c *Client) AddStrike(channel, userID string, t time.Time, expire time.Duration) (int, error) {
+	defer metrics.ObserveState("AddStrike")()
+
+	strikes := Strikes{Channel: channel, UserID: userID}
+
+	col := c.mongo.Database("chb3").Collection("strikes")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "channel", Value: channel},
+		{Key: "userid", Value: userID},
+	}
+	if err := col.FindOne(ctx, filter).Decode(&strikes); err != nil && err != mongo.ErrNoDocuments {
+		return 0, err
+	}
+
+	if t.Sub(strikes.Last) > expire {
+		strikes.Count = 0
+	}
+	strikes.Count++
+	strikes.Last = t
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err := col.ReplaceOne(ctx, filter, strikes, opts); err != nil {
+		return 0, err
+	}
+
+	return strikes.Count, nil
+}
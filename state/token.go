@@ -0,0 +1,57 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/chronophylos/chb3/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Token is an OAuth user access token of twitch.
+type Token struct {
+	// Login is the twitch user the token belongs to.
+	Login        string
+	AccessToken  string
+	RefreshToken string
+	Scopes       []string
+	ExpiresAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// GetToken gets the token of the twitch user login. It returns
+// mongo.ErrNoDocuments if there is none.
+func (c *Client) GetToken(login string) (*Token, error) {
+	defer metrics.ObserveState("GetToken")()
+
+	var token *Token
+
+	col := c.mongo.Database("chb3").Collection("tokens")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.D{{Key: "login", Value: login}}
+	if err := col.FindOne(ctx, filter).Decode(&token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// SetToken stores token and replaces the previous token of the same user.
+func (c *Client) SetToken(token *Token) error {
+	defer metrics.ObserveState("SetToken")()
+
+	col := c.mongo.Database("chb3").Collection("tokens")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token.UpdatedAt = time.Now()
+
+	filter := bson.D{{Key: "login", Value: token.Login}}
+	opts := options.Replace().SetUpsert(true)
+	_, err := col.ReplaceOne(ctx, filter, token, opts)
+
+	return err
+}
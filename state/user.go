@@ -38,6 +38,12 @@ type User struct {
 
 	IsRegular bool
 
+	// Language overrides the language of the channel for this user.
+	Language string
+
+	// LogOptOut is true if the messages of the user must not be logged.
+	LogOptOut bool
+
 	Firstseen time.Time
 	Lastseen  time.Time
 
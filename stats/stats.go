@@ -0,0 +1,212 @@
+// Package stats counts chat messages in memory and writes the counts to the
+// database in batches.
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Store stores message counts, eg. *state.Client. Counts are added in
+// batches and adding a batch again must not count it twice. If a write fails
+// it returns the counts that may not have been stored.
+type Store interface {
+	AddUserStats(batch string, users []state.UserStats) ([]state.UserStats, error)
+	AddChannelStats(batch string, channels []state.ChannelStats) ([]state.ChannelStats, error)
+}
+
+// batch are counts flushed together. Counts that failed to write are retried
+// with the same ID.
+type batch struct {
+	id       string
+	users    []state.UserStats
+	channels []state.ChannelStats
+}
+
+type userKey struct {
+	channel string
+	userID  string
+	day     time.Time
+}
+
+type channelKey struct {
+	channel string
+	hour    time.Time
+}
+
+// Counter counts messages per user and day and per channel and hour until
+// they are flushed.
+type Counter struct {
+	store Store
+
+	mu       sync.Mutex
+	users    map[userKey]*state.UserStats
+	channels map[channelKey]*state.ChannelStats
+	retries  []batch
+}
+
+// NewCounter creates a counter flushing to store.
+func NewCounter(store Store) *Counter {
+	return &Counter{
+		store:    store,
+		users:    map[userKey]*state.UserStats{},
+		channels: map[channelKey]*state.ChannelStats{},
+	}
+}
+
+// Day returns the start of the day of t in UTC.
+func Day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// Add counts a message of the user with the twitch ID userID and the name
+// userName in channel at t.
+func (c *Counter) Add(channel, userID, userName string, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.add(state.UserStats{
+		Channel:  channel,
+		UserID:   userID,
+		UserName: userName,
+		Day:      Day(t),
+		Messages: 1,
+	}, state.ChannelStats{
+		Channel:  channel,
+		Hour:     t.UTC().Truncate(time.Hour),
+		Messages: 1,
+	})
+}
+
+// add adds the counts of user and channel. c.mu must be locked.
+func (c *Counter) add(user state.UserStats, channel state.ChannelStats) {
+	if user.Messages > 0 {
+		key := userKey{user.Channel, user.UserID, user.Day}
+		if stats, ok := c.users[key]; ok {
+			stats.Messages += user.Messages
+			stats.UserName = user.UserName
+		} else {
+			c.users[key] = &user
+		}
+	}
+
+	if channel.Messages > 0 {
+		key := channelKey{channel.Channel, channel.Hour}
+		if stats, ok := c.channels[key]; ok {
+			stats.Messages += channel.Messages
+		} else {
+			c.channels[key] = &channel
+		}
+	}
+}
+
+// Pending returns the counts of channel that were not flushed yet. Replies
+// add them to the stored counts so they don't have to flush.
+func (c *Counter) Pending(channel string) ([]state.UserStats, []state.ChannelStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var users []state.UserStats
+	for key, stats := range c.users {
+		if key.channel == channel {
+			users = append(users, *stats)
+		}
+	}
+	var channels []state.ChannelStats
+	for key, stats := range c.channels {
+		if key.channel == channel {
+			channels = append(channels, *stats)
+		}
+	}
+
+	for _, b := range c.retries {
+		for _, stats := range b.users {
+			if stats.Channel == channel {
+				users = append(users, stats)
+			}
+		}
+		for _, stats := range b.channels {
+			if stats.Channel == channel {
+				channels = append(channels, stats)
+			}
+		}
+	}
+
+	return users, channels
+}
+
+// Flush writes all counts to the store. Counts the store could not write are
+// retried with the next flush.
+func (c *Counter) Flush() error {
+	c.mu.Lock()
+	batches := c.retries
+	c.retries = nil
+	if len(c.users) > 0 || len(c.channels) > 0 {
+		b := batch{id: primitive.NewObjectID().Hex()}
+		for _, stats := range c.users {
+			b.users = append(b.users, *stats)
+		}
+		for _, stats := range c.channels {
+			b.channels = append(b.channels, *stats)
+		}
+		batches = append(batches, b)
+		c.users = map[userKey]*state.UserStats{}
+		c.channels = map[channelKey]*state.ChannelStats{}
+	}
+	c.mu.Unlock()
+
+	var userErr, channelErr error
+	var retries []batch
+	for _, b := range batches {
+		failed := batch{id: b.id}
+
+		var err error
+		if failed.users, err = c.store.AddUserStats(b.id, b.users); err != nil && userErr == nil {
+			userErr = err
+		}
+		if failed.channels, err = c.store.AddChannelStats(b.id, b.channels); err != nil && channelErr == nil {
+			channelErr = err
+		}
+
+		if len(failed.users) > 0 || len(failed.channels) > 0 {
+			retries = append(retries, failed)
+		}
+	}
+
+	c.mu.Lock()
+	c.retries = append(c.retries, retries...)
+	c.mu.Unlock()
+
+	if userErr != nil {
+		return fmt.Errorf("adding user stats: %v", userErr)
+	}
+	if channelErr != nil {
+		return fmt.Errorf("adding channel stats: %v", channelErr)
+	}
+	return nil
+}
+
+// Run flushes the counts every interval until ctx is done. Call Flush
+// afterwards to write the remaining counts.
+func (c *Counter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.Flush(); err != nil {
+			log.Error().Err(err).Msg("Flushing stats")
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package stats
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore adds counts of the same user and day or channel and hour like
+// the database. Batches are only added once.
+type fakeStore struct {
+	err        error
+	channelErr error
+	users      []state.UserStats
+	channels   []state.ChannelStats
+	// timeout adds the user counts but reports them as failed
+	timeout error
+	batches []string
+	added   map[string]bool
+}
+
+func (s *fakeStore) AddUserStats(batch string, users []state.UserStats) ([]state.UserStats, error) {
+	if s.err != nil {
+		return users, s.err
+	}
+	s.batches = append(s.batches, batch)
+	for _, stats := range users {
+		if s.once(batch, stats.Channel+stats.UserID+stats.Day.String()) {
+			s.addUser(stats)
+		}
+	}
+	if s.timeout != nil {
+		return users, s.timeout
+	}
+	return nil, nil
+}
+
+func (s *fakeStore) AddChannelStats(batch string, channels []state.ChannelStats) ([]state.ChannelStats, error) {
+	if s.err != nil {
+		return channels, s.err
+	}
+	s.batches = append(s.batches, batch)
+	var failed []state.ChannelStats
+	for i, stats := range channels {
+		// Only the first count fails like a write error of a bulk write
+		if s.channelErr != nil && i == 0 {
+			failed = append(failed, stats)
+			continue
+		}
+		if s.once(batch, stats.Channel+stats.Hour.String()) {
+			s.addChannel(stats)
+		}
+	}
+	if failed != nil {
+		return failed, s.channelErr
+	}
+	return nil, nil
+}
+
+// once reports wheather batch was not added to key before.
+func (s *fakeStore) once(batch, key string) bool {
+	if s.added == nil {
+		s.added = map[string]bool{}
+	}
+	if s.added[batch+key] {
+		return false
+	}
+	s.added[batch+key] = true
+	return true
+}
+
+func (s *fakeStore) addUser(stats state.UserStats) {
+	for i, stored := range s.users {
+		if stored.Channel == stats.Channel && stored.UserID == stats.UserID && stored.Day.Equal(stats.Day) {
+			s.users[i].Messages += stats.Messages
+			return
+		}
+	}
+	s.users = append(s.users, stats)
+}
+
+func (s *fakeStore) addChannel(stats state.ChannelStats) {
+	for i, stored := range s.channels {
+		if stored.Channel == stats.Channel && stored.Hour.Equal(stats.Hour) {
+			s.channels[i].Messages += stats.Messages
+			return
+		}
+	}
+	s.channels = append(s.channels, stats)
+}
+
+func TestCounter(t *testing.T) {
+	store := &fakeStore{err: errors.New("offline")}
+	c := NewCounter(store)
+
+	now := time.Date(2020, 5, 1, 12, 30, 0, 0, time.UTC)
+	c.Add("chronophylos", "1", "someone", now)
+	c.Add("chronophylos", "1", "someone", now.Add(time.Minute))
+	c.Add("chronophylos", "2", "other", now.Add(time.Hour))
+	c.Add("chronophylos", "1", "someone", now.Add(24*time.Hour))
+
+	// Counts are kept if the store fails
+	assert.Error(t, c.Flush())
+	c.Add("chronophylos", "2", "other", now.Add(time.Hour))
+
+	store.err = nil
+	assert.NoError(t, c.Flush())
+
+	sort.Slice(store.users, func(i, j int) bool {
+		a, b := store.users[i], store.users[j]
+		return a.Day.Before(b.Day) || a.Day.Equal(b.Day) && a.UserID < b.UserID
+	})
+	assert.Equal(t, []state.UserStats{
+		{Channel: "chronophylos", UserID: "1", UserName: "someone", Day: Day(now), Messages: 2},
+		{Channel: "chronophylos", UserID: "2", UserName: "other", Day: Day(now), Messages: 2},
+		{Channel: "chronophylos", UserID: "1", UserName: "someone", Day: Day(now).Add(24 * time.Hour), Messages: 1},
+	}, store.users)
+
+	sort.Slice(store.channels, func(i, j int) bool {
+		return store.channels[i].Hour.Before(store.channels[j].Hour)
+	})
+	assert.Equal(t, []state.ChannelStats{
+		{Channel: "chronophylos", Hour: now.Truncate(time.Hour), Messages: 2},
+		{Channel: "chronophylos", Hour: now.Truncate(time.Hour).Add(time.Hour), Messages: 2},
+		{Channel: "chronophylos", Hour: now.Truncate(time.Hour).Add(24 * time.Hour), Messages: 1},
+	}, store.channels)
+
+	// Nothing left to flush
+	store.users, store.channels = nil, nil
+	assert.NoError(t, c.Flush())
+	assert.Empty(t, store.users)
+}
+
+func TestCounterPartialFailure(t *testing.T) {
+	store := &fakeStore{channelErr: errors.New("write error")}
+	c := NewCounter(store)
+
+	now := time.Date(2020, 5, 1, 12, 30, 0, 0, time.UTC)
+	c.Add("chronophylos", "1", "someone", now)
+	c.Add("chronophylos", "1", "someone", now.Add(time.Hour))
+
+	// Users were written, only one channel count failed
+	assert.Error(t, c.Flush())
+	assert.Len(t, store.users, 1)
+	assert.Len(t, store.channels, 1)
+
+	// The failed count is retried in the pending counts and its batch
+	users, channels := c.Pending("chronophylos")
+	assert.Empty(t, users)
+	assert.Len(t, channels, 1)
+
+	store.channelErr = nil
+	store.users = nil
+	assert.NoError(t, c.Flush())
+	assert.Empty(t, store.users, "written counts are not written again")
+	assert.Len(t, store.channels, 2)
+	assert.Equal(t, store.batches[0], store.batches[len(store.batches)-1], "failed counts are retried in their batch")
+
+	var total int
+	for _, stats := range store.channels {
+		total += stats.Messages
+	}
+	assert.Equal(t, 2, total, "no message is counted twice")
+}
+
+func TestCounterPending(t *testing.T) {
+	store := &fakeStore{}
+	c := NewCounter(store)
+
+	now := time.Date(2020, 5, 1, 12, 30, 0, 0, time.UTC)
+	c.Add("chronophylos", "1", "someone", now)
+	c.Add("chronophylos", "1", "someone", now)
+	c.Add("other", "2", "other", now)
+
+	users, channels := c.Pending("chronophylos")
+	assert.Equal(t, []state.UserStats{
+		{Channel: "chronophylos", UserID: "1", UserName: "someone", Day: Day(now), Messages: 2},
+	}, users)
+	assert.Equal(t, []state.ChannelStats{
+		{Channel: "chronophylos", Hour: now.Truncate(time.Hour), Messages: 2},
+	}, channels)
+
+	require.NoError(t, c.Flush())
+	users, channels = c.Pending("chronophylos")
+	assert.Empty(t, users)
+	assert.Empty(t, channels)
+}
+
+func TestCounterTimeout(t *testing.T) {
+	store := &fakeStore{timeout: errors.New("context deadline exceeded")}
+	c := NewCounter(store)
+
+	now := time.Date(2020, 5, 1, 12, 30, 0, 0, time.UTC)
+	c.Add("chronophylos", "1", "someone", now)
+
+	// The counts were written but the store can't tell
+	assert.Error(t, c.Flush())
+	store.timeout = nil
+	assert.NoError(t, c.Flush())
+
+	require.Len(t, store.users, 1)
+	assert.Equal(t, 1, store.users[0].Messages, "retries don't count twice")
+}
@@ -0,0 +1,245 @@
+// Package token keeps the twitch user access token of the bot valid. Tokens
+// are stored in the state and refreshed before they expire.
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/nicklaw5/helix"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNoToken is returned by Load if no token is stored.
+var ErrNoToken = errors.New("no token stored, run `chb3 auth` first")
+
+// ValidateInterval is how often a token is validated. Twitch requires apps
+// to validate their tokens every hour.
+const ValidateInterval = time.Hour
+
+// Helix is the part of the helix client a Source needs.
+type Helix interface {
+	GetUserAccessToken(code string) (*helix.UserAccessTokenResponse, error)
+	RefreshUserAccessToken(refreshToken string) (*helix.RefreshTokenResponse, error)
+	ValidateToken(accessToken string) (bool, *helix.ValidateTokenResponse, error)
+}
+
+// Store persists tokens.
+type Store interface {
+	GetToken(login string) (*state.Token, error)
+	SetToken(token *state.Token) error
+}
+
+// Source provides the current access token of a twitch user.
+type Source struct {
+	// Margin is how long before expiry a token is refreshed.
+	Margin time.Duration
+
+	login string
+	helix Helix
+	store Store
+	log   zerolog.Logger
+
+	mu    sync.RWMutex
+	token *state.Token
+}
+
+// NewSource creates a Source for the twitch user login.
+func NewSource(login string, h Helix, store Store) *Source {
+	return &Source{
+		Margin: 10 * time.Minute,
+		login:  login,
+		helix:  h,
+		store:  store,
+		log:    log.With().Str("login", login).Logger(),
+	}
+}
+
+// Load gets the stored token and makes sure it is valid.
+func (s *Source) Load() error {
+	token, err := s.store.GetToken(s.login)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrNoToken
+		}
+		return fmt.Errorf("getting token: %v", err)
+	}
+
+	s.mu.Lock()
+	s.token = token
+	s.mu.Unlock()
+
+	return s.Validate()
+}
+
+// AccessToken returns the current access token.
+func (s *Source) AccessToken() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.token == nil {
+		return ""
+	}
+	return s.token.AccessToken
+}
+
+// IRCToken returns the current access token in the format expected by IRC.
+func (s *Source) IRCToken() string {
+	return "oauth:" + s.AccessToken()
+}
+
+// ExpiresAt returns when the current token expires.
+func (s *Source) ExpiresAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.token == nil {
+		return time.Time{}
+	}
+	return s.token.ExpiresAt
+}
+
+// Validate checks the current token with twitch and refreshes it if it is
+// not valid anymore.
+func (s *Source) Validate() error {
+	valid, resp, err := s.helix.ValidateToken(s.AccessToken())
+	if err != nil {
+		return fmt.Errorf("validating token: %v", err)
+	}
+
+	if !valid {
+		s.log.Info().Msg("Token is invalid, refreshing it")
+		return s.Refresh()
+	}
+
+	if resp.Data.Login != s.login {
+		return fmt.Errorf("token belongs to %s instead of %s", resp.Data.Login, s.login)
+	}
+
+	return nil
+}
+
+// Refresh gets a new access token with the refresh token and stores it.
+func (s *Source) Refresh() error {
+	s.mu.RLock()
+	var refreshToken string
+	if s.token != nil {
+		refreshToken = s.token.RefreshToken
+	}
+	s.mu.RUnlock()
+
+	if refreshToken == "" {
+		return errors.New("token has no refresh token")
+	}
+
+	resp, err := s.helix.RefreshUserAccessToken(refreshToken)
+	if err != nil {
+		return fmt.Errorf("refreshing token: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("refreshing token: %d %s", resp.StatusCode, resp.ErrorMessage)
+	}
+
+	if err := s.set(resp.Data); err != nil {
+		return err
+	}
+
+	s.log.Info().
+		Time("expires", s.ExpiresAt()).
+		Msg("Refreshed token")
+
+	return nil
+}
+
+// Exchange gets a token for an authorization code, validates and stores it.
+func (s *Source) Exchange(code string) error {
+	resp, err := s.helix.GetUserAccessToken(code)
+	if err != nil {
+		return fmt.Errorf("exchanging code: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("exchanging code: %d %s", resp.StatusCode, resp.ErrorMessage)
+	}
+
+	valid, validated, err := s.helix.ValidateToken(resp.Data.AccessToken)
+	if err != nil {
+		return fmt.Errorf("validating token: %v", err)
+	}
+	if !valid {
+		return errors.New("twitch returned an invalid token")
+	}
+	if validated.Data.Login != s.login {
+		return fmt.Errorf("token belongs to %s instead of %s", validated.Data.Login, s.login)
+	}
+
+	return s.set(resp.Data)
+}
+
+// Run validates the token every ValidateInterval and refreshes it Margin
+// before it expires until ctx is done.
+func (s *Source) Run(ctx context.Context) {
+	for {
+		wait := ValidateInterval
+		refresh := false
+
+		if expires := s.ExpiresAt(); !expires.IsZero() {
+			if until := time.Until(expires) - s.Margin; until < wait {
+				wait = until
+				refresh = true
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		var err error
+		if refresh {
+			err = s.Refresh()
+		} else {
+			err = s.Validate()
+		}
+
+		if err != nil {
+			s.log.Error().Err(err).Msg("Keeping token valid")
+
+			// Don't hammer twitch if refreshing fails
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Minute):
+			}
+		}
+	}
+}
+
+func (s *Source) set(creds helix.UserAccessCredentials) error {
+	token := &state.Token{
+		Login:        s.login,
+		AccessToken:  creds.AccessToken,
+		RefreshToken: creds.RefreshToken,
+		Scopes:       creds.Scopes,
+	}
+	if creds.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(creds.ExpiresIn) * time.Second)
+	}
+
+	if err := s.store.SetToken(token); err != nil {
+		return fmt.Errorf("storing token: %v", err)
+	}
+
+	s.mu.Lock()
+	s.token = token
+	s.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package token
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chronophylos/chb3/state"
+	"github.com/nicklaw5/helix"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type fakeStore struct {
+	tokens map[string]*state.Token
+}
+
+func (s *fakeStore) GetToken(login string) (*state.Token, error) {
+	token, ok := s.tokens[login]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return token, nil
+}
+
+func (s *fakeStore) SetToken(token *state.Token) error {
+	s.tokens[token.Login] = token
+	return nil
+}
+
+// fakeHelix accepts the access token valid for the user login.
+type fakeHelix struct {
+	valid string
+	login string
+}
+
+func (h *fakeHelix) GetUserAccessToken(code string) (*helix.UserAccessTokenResponse, error) {
+	resp := &helix.UserAccessTokenResponse{}
+	resp.StatusCode = http.StatusOK
+	resp.Data = helix.UserAccessCredentials{AccessToken: h.valid, RefreshToken: "refresh", ExpiresIn: 3600}
+	return resp, nil
+}
+
+func (h *fakeHelix) RefreshUserAccessToken(refreshToken string) (*helix.RefreshTokenResponse, error) {
+	resp := &helix.RefreshTokenResponse{}
+	if refreshToken != "refresh" {
+		resp.StatusCode = http.StatusBadRequest
+		resp.ErrorMessage = "Invalid refresh token"
+		return resp, nil
+	}
+	resp.StatusCode = http.StatusOK
+	resp.Data = helix.UserAccessCredentials{AccessToken: h.valid, RefreshToken: "refresh", ExpiresIn: 3600}
+	return resp, nil
+}
+
+func (h *fakeHelix) ValidateToken(accessToken string) (bool, *helix.ValidateTokenResponse, error) {
+	resp := &helix.ValidateTokenResponse{}
+	resp.Data.Login = h.login
+	return accessToken == h.valid, resp, nil
+}
+
+func TestLoad(t *testing.T) {
+	store := &fakeStore{tokens: map[string]*state.Token{}}
+	h := &fakeHelix{valid: "new", login: "chronophylosbot"}
+	s := NewSource("chronophylosbot", h, store)
+
+	assert.Equal(t, ErrNoToken, s.Load())
+
+	store.tokens["chronophylosbot"] = &state.Token{
+		Login:        "chronophylosbot",
+		AccessToken:  "expired",
+		RefreshToken: "refresh",
+	}
+
+	if assert.NoError(t, s.Load()) {
+		assert.Equal(t, "oauth:new", s.IRCToken())
+		assert.Equal(t, "new", store.tokens["chronophylosbot"].AccessToken)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), s.ExpiresAt(), time.Minute)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	store := &fakeStore{tokens: map[string]*state.Token{}}
+
+	s := NewSource("chronophylosbot", &fakeHelix{valid: "token", login: "chronophylos"}, store)
+	assert.Error(t, s.Exchange("code"), "token of another user")
+
+	s = NewSource("chronophylosbot", &fakeHelix{valid: "token", login: "chronophylosbot"}, store)
+	if assert.NoError(t, s.Exchange("code")) {
+		assert.Equal(t, "token", store.tokens["chronophylosbot"].AccessToken)
+	}
+}
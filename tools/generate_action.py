@@ -11,30 +11,12 @@ def main():
     newActionFunction = "new" + actionName
     actionName = actionName[0].lower() + actionName[1:]
 
-    actionsGo = "cmd/actions/actions.go"
     actionFile = "cmd/actions/" + name.lower().replace(" ", "_") + ".go"
 
-    addActionEntry = True
-
     if exists(actionFile):
         p = input("Action already exists do you want to overwrite it? [y/N] ").lower()
         if p not in ["y", "yes"]:
             return
-        else:
-            addActionEntry = False
-
-    if addActionEntry:
-        print("Adding Action to " + actionsGo)
-
-        with open(actionsGo, "r") as f:
-            lines = f.readlines()
-
-        i = lines.index("var actions = Actions{\n")
-        i = lines.index("}\n", i + 1)
-        lines.insert(i - 1, "\t" + newActionFunction + "(),\n")
-
-        with open(actionsGo, "w") as f:
-            f.writelines(lines)
 
     print("Writing Action File: " + actionFile)
     with open(actionFile, "w") as f:
@@ -46,6 +28,10 @@ import (
     "regexp"
 )
 
+func init() {{
+    Register(PriorityNormal, {newActionFunction}())
+}}
+
 type {actionName} struct {{
     options *Options
 }}
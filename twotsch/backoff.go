@@ -0,0 +1,44 @@
+package twotsch
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff computes exponentially growing delays with jitter.
+type Backoff struct {
+	// Min is the delay after the first failure.
+	Min time.Duration
+	// Max caps the delay.
+	Max time.Duration
+	// Factor is multiplied with the delay after every failure.
+	Factor float64
+	// Jitter is the fraction by which a delay is randomized, eg. 0.2 means
+	// ±20%.
+	Jitter float64
+
+	attempt int
+}
+
+// Next returns the delay before the next attempt.
+func (b *Backoff) Next() time.Duration {
+	d := float64(b.Min)
+	for i := 0; i < b.attempt && d < float64(b.Max); i++ {
+		d *= b.Factor
+	}
+	if d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	b.attempt++
+
+	if b.Jitter > 0 {
+		d += d * b.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(d)
+}
+
+// Reset starts over with Min.
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
@@ -0,0 +1,28 @@
+package twotsch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	b := &Backoff{Min: time.Second, Max: 10 * time.Second, Factor: 2}
+
+	for _, want := range []time.Duration{1, 2, 4, 8, 10, 10} {
+		assert.Equal(t, want*time.Second, b.Next())
+	}
+
+	b.Reset()
+	assert.Equal(t, time.Second, b.Next())
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := &Backoff{Min: time.Second, Max: time.Second, Factor: 2, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		d := b.Next()
+		assert.True(t, d >= 500*time.Millisecond && d <= 1500*time.Millisecond, d)
+	}
+}
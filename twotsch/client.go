@@ -1,21 +1,247 @@
+// Package twotsch wraps the twitch client and routes every outgoing message
+// through a queue so pending messages can be flushed before disconnecting.
 package twotsch
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/gempir/go-twitch-irc/v2"
+	"github.com/rs/zerolog/log"
 )
 
+// FlushDelay is how long Flush waits after the queue is empty. go-twitch-irc
+// writes to the connection asynchronously and has no way to tell when it is
+// done.
+var FlushDelay = 500 * time.Millisecond
+
+type message struct {
+	channel string
+	text    string
+}
+
+// Client is a twitch client whose Say is queued.
 type Client struct {
-	client *twitch.Client
+	*twitch.Client
+
+	queue   chan message
+	pending sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	token        func() string
+	check        func(text string) (string, error)
+	onConnect    func()
+	onDisconnect func(err error)
+	connected    int32
+	reconnects   int64
+
+	modMu sync.RWMutex
+	mod   map[string]bool
+}
+
+// NewClient creates a client for username with the oauth token.
+func NewClient(username, oauth string) *Client {
+	c := &Client{
+		Client: twitch.NewClient(username, oauth),
+		queue:  make(chan message, 64),
+		mod:    map[string]bool{},
+	}
+	c.Client.OnConnect(c.handleConnect)
+	c.Client.OnUserStateMessage(c.handleUserState)
+
+	go c.run()
+
+	return c
+}
+
+// SetOutboundFilter sets the function every message passed to Say is checked
+// with. It returns the text to send or an error if the message must not be
+// sent.
+func (c *Client) SetOutboundFilter(check func(text string) (string, error)) {
+	c.check = check
+}
+
+// Say queues text to be sent to channel after checking it with the outbound
+// filter. Messages are dropped once Flush was called.
+func (c *Client) Say(channel, text string) {
+	if c.check != nil {
+		checked, err := c.check(text)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("channel", channel).
+				Str("message", text).
+				Msg("Not sending message")
+			return
+		}
+		text = checked
+	}
+
+	c.enqueue(channel, text)
+}
+
+// Command queues a chat command like `/timeout someone 60` for channel. It
+// is not checked by the outbound filter.
+func (c *Client) Command(channel, command string) {
+	c.enqueue(channel, command)
+}
+
+func (c *Client) enqueue(channel, text string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		log.Warn().
+			Str("channel", channel).
+			Str("message", text).
+			Msg("Dropping message while shutting down")
+		return
+	}
+
+	c.pending.Add(1)
+	c.queue <- message{channel: channel, text: text}
+}
+
+// IsModerator reports wheather the bot is a moderator or the broadcaster in
+// channel. It is known once twitch confirmed joining the channel.
+func (c *Client) IsModerator(channel string) bool {
+	c.modMu.RLock()
+	defer c.modMu.RUnlock()
+
+	return c.mod[channel]
 }
 
-func NewClient() *Client {
-	return &Client{}
+// Flush stops accepting new messages and waits until every queued message was
+// handed to the connection or ctx is done.
+func (c *Client) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(FlushDelay):
+		return nil
+	}
+}
+
+// SetTokenSource sets the function called before every connect to get the
+// current oauth token.
+func (c *Client) SetTokenSource(token func() string) {
+	c.token = token
+}
+
+// OnConnect sets the callback called every time the client connected.
+func (c *Client) OnConnect(callback func()) {
+	c.onConnect = callback
 }
 
-func (c *Client) Say(channel, message string) {
+// OnDisconnect sets the callback called by Run every time the client was
+// disconnected.
+func (c *Client) OnDisconnect(callback func(err error)) {
+	c.onDisconnect = callback
+}
+
+// Reconnects returns how often the client reconnected since it was created.
+func (c *Client) Reconnects() int64 {
+	return atomic.LoadInt64(&c.reconnects)
+}
+
+// Run connects to chat and reconnects with b until ctx is done or the login
+// failed. Before every reconnect rejoin is called to get the channels to
+// join.
+func (c *Client) Run(ctx context.Context, b *Backoff, rejoin func() ([]string, error)) error {
+	for {
+		atomic.StoreInt32(&c.connected, 0)
+
+		if c.token != nil {
+			c.SetIRCToken(c.token())
+		}
+
+		log.Info().Msg("Connecting to chat")
+		err := c.Connect()
+		if c.onDisconnect != nil {
+			c.onDisconnect(err)
+		}
 
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, twitch.ErrLoginAuthenticationFailed) {
+			return err
+		}
+
+		// Only back off further if we could not even connect
+		if atomic.LoadInt32(&c.connected) == 1 {
+			b.Reset()
+		}
+
+		wait := b.Next()
+		log.Warn().
+			Err(err).
+			Dur("wait", wait).
+			Msg("Disconnected from chat")
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+
+		atomic.AddInt64(&c.reconnects, 1)
+
+		channels, err := rejoin()
+		if err != nil {
+			log.Error().Err(err).Msg("Getting channels to rejoin")
+			continue
+		}
+		c.Join(channels...)
+	}
 }
 
+// Raw returns the wrapped twitch client.
 func (c *Client) Raw() *twitch.Client {
-	return c.client
+	return c.Client
+}
+
+func (c *Client) handleConnect() {
+	atomic.StoreInt32(&c.connected, 1)
+
+	if c.onConnect != nil {
+		c.onConnect()
+	}
+}
+
+func (c *Client) handleUserState(message twitch.UserStateMessage) {
+	_, mod := message.User.Badges["moderator"]
+	_, broadcaster := message.User.Badges["broadcaster"]
+
+	c.modMu.Lock()
+	c.mod[message.Channel] = mod || broadcaster
+	c.modMu.Unlock()
+}
+
+func (c *Client) run() {
+	for msg := range c.queue {
+		c.Client.Say(msg.channel, msg.text)
+		c.pending.Done()
+	}
 }
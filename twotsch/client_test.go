@@ -0,0 +1,25 @@
+package twotsch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlush(t *testing.T) {
+	FlushDelay = 0
+
+	c := NewClient("justinfan123", "oauth:123")
+	c.Say("chronophylos", "hello")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, c.Flush(ctx))
+
+	// Dropped because the client is flushed
+	c.Say("chronophylos", "bye")
+	assert.NoError(t, c.Flush(ctx))
+}
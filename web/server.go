@@ -0,0 +1,95 @@
+// Package web provides the optional HTTP server of the bot. Other packages
+// mount their handlers on it, eg. the admin API.
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Server is a HTTP server with public and authenticated routes.
+type Server struct {
+	mux   *http.ServeMux
+	srv   *http.Server
+	token string
+}
+
+// NewServer creates a server listening on addr. Routes added with HandleAuth
+// require the header `Authorization: Bearer <token>`.
+func NewServer(addr, token string) *Server {
+	mux := http.NewServeMux()
+
+	return &Server{
+		mux: mux,
+		srv: &http.Server{
+			Addr:         addr,
+			Handler:      mux,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 30 * time.Second,
+		},
+		token: token,
+	}
+}
+
+// Handle registers a public handler for pattern.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// HandleAuth registers a handler for pattern that requires the token.
+func (s *Server) HandleAuth(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, s.requireToken(handler))
+}
+
+// ListenAndServe serves until Shutdown is called.
+func (s *Server) ListenAndServe() error {
+	log.Info().Str("addr", s.srv.Addr).Msg("Starting HTTP server")
+
+	if err := s.srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops the server gracefully.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+func (s *Server) requireToken(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			log.Warn().
+				Str("remote", r.RemoteAddr).
+				Str("path", r.URL.Path).
+				Msg("Unauthorized HTTP request")
+			WriteError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// WriteJSON writes v as JSON with status code status.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("Encoding JSON response")
+	}
+}
+
+// WriteError writes a JSON error with status code status.
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteJSON(w, status, map[string]string{"error": message})
+}